@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/gob"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/alexedwards/scs/pgxstore"
@@ -18,6 +21,10 @@ import (
 	"github.com/joho/godotenv"
 
 	"github.com/nathancamolez-dev/go-bid/internal/api"
+	"github.com/nathancamolez-dev/go-bid/internal/identity"
+	"github.com/nathancamolez-dev/go-bid/internal/jwt"
+	"github.com/nathancamolez-dev/go-bid/internal/mailer"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
 	"github.com/nathancamolez-dev/go-bid/internal/services"
 )
 
@@ -50,27 +57,249 @@ func main() {
 	s.Cookie.HttpOnly = true
 	s.Cookie.SameSite = http.SameSiteLaxMode
 
+	var pubsub services.PubSub
+	var locker services.RoomLocker
+	switch os.Getenv("GOBID_PUBSUB_DRIVER") {
+	case "postgres":
+		pubsub = services.NewPgPubSub(pool)
+		locker = services.NewPgRoomLocker(pool)
+	default:
+		pubsub = services.NewLocalPubSub()
+		locker = services.LocalRoomLocker{}
+	}
+
+	var rates money.Rates
+	if path := os.Getenv("GOBID_EXCHANGE_RATES_FILE"); path != "" {
+		rates, err = money.LoadRates(path)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	jwtKeys, err := loadJWTKeys()
+	if err != nil {
+		panic(err)
+	}
+
+	providers, err := loadIdentityProviders(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	trustedProxies, err := loadTrustedProxies()
+	if err != nil {
+		panic(err)
+	}
+
+	csrfConfig, err := loadCSRFConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	var mail mailer.Mailer
+	switch os.Getenv("GOBID_MAIL_DRIVER") {
+	case "smtp":
+		mail = mailer.SMTPMailer{
+			Addr:     os.Getenv("GOBID_SMTP_ADDR"),
+			Username: os.Getenv("GOBID_SMTP_USER"),
+			Password: os.Getenv("GOBID_SMTP_PASSWORD"),
+			From:     os.Getenv("GOBID_MAIL_FROM"),
+		}
+	case "log":
+		mail = mailer.LogMailer{}
+	default:
+		dir := os.Getenv("GOBID_MAIL_DIR")
+		if dir == "" {
+			dir = "./tmp/mail"
+		}
+		mail = mailer.FileMailer{Dir: dir, From: os.Getenv("GOBID_MAIL_FROM")}
+	}
+
+	senders := []services.NotificationSender{
+		services.EmailNotificationSender{Mailer: mail},
+	}
+	if url := os.Getenv("GOBID_NOTIFICATION_WEBHOOK_URL"); url != "" {
+		senders = append(senders, services.WebhookNotificationSender{
+			URL:    url,
+			Secret: os.Getenv("GOBID_NOTIFICATION_WEBHOOK_SECRET"),
+			Client: &http.Client{Timeout: 10 * time.Second},
+		})
+	}
+	go services.NewNotificationDispatcher(pool, senders...).Run(ctx)
+	go services.NewAccountPurger(pool).Run(ctx)
+
+	bidsServices := services.NewBidsServices(pool)
+
 	api := api.Api{
-		Router:         chi.NewRouter(),
-		UserService:    services.NewUserService(pool),
-		ProductService: services.NewProductService(pool),
-		BidsServices:   services.NewBidsServices(pool),
-		Sessions:       s,
-		WsUpgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
-		},
+		Router:            chi.NewRouter(),
+		UserService:       services.NewUserService(pool, mail),
+		ProductService:    services.NewProductService(pool),
+		BidsServices:      bidsServices,
+		WatchlistService:  services.NewWatchlistService(pool),
+		FeedbackService:   services.NewFeedbackService(pool),
+		SessionService:    services.NewSessionService(pool),
+		ApiTokenService:   services.NewApiTokenService(pool),
+		AuthTokenService:  services.NewAuthTokenService(pool, jwtKeys),
+		IdentityProviders: providers,
+		AdminService:      services.NewAdminService(pool),
+		WsTicketService:   services.NewWsTicketService(pool),
+		Sessions:          s,
+		CSRF:              csrfConfig,
+		WsAllowedOrigins:  loadWsAllowedOrigins(csrfConfig.TrustedOrigins),
+		TrustedProxies:    trustedProxies,
 		AuctionLobby: services.AuctionLobby{
-			Rooms: make(map[uuid.UUID]*services.AuctionRoom),
+			Rooms:        make(map[uuid.UUID]*services.AuctionRoom),
+			InstanceId:   uuid.New(),
+			PubSub:       pubsub,
+			Locker:       locker,
+			Rates:        rates,
+			BidsServices: bidsServices,
 		},
 	}
 
+	api.WsUpgrader = websocket.Upgrader{CheckOrigin: api.CheckOrigin}
+
+	if err := api.AuctionLobby.Listen(ctx); err != nil {
+		panic(err)
+	}
+
 	api.BindRoutes()
 
-	slog.Info("Server Running on port :3080")
-	if err := http.ListenAndServe("localhost:3080", api.Router); err != nil {
+	addr := os.Getenv("GOBID_HTTP_ADDR")
+	if addr == "" {
+		addr = "localhost:3080"
+	}
+
+	slog.Info("Server Running", "addr", addr, "instance_id", api.AuctionLobby.InstanceId)
+	if err := http.ListenAndServe(addr, api.Router); err != nil {
 		panic(err)
 	}
 
 }
+
+// loadJWTKeys reads the access token signing keys from GOBID_JWT_KEYS, as
+// "kid:base64key" pairs separated by commas, signing with GOBID_JWT_KEY_ID.
+// Without them a random key is used, which only suits a single instance as
+// tokens don't survive restarts.
+func loadJWTKeys() (*jwt.Keyring, error) {
+	spec := os.Getenv("GOBID_JWT_KEYS")
+	if spec == "" {
+		slog.Warn("GOBID_JWT_KEYS is not set, signing access tokens with a random key")
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		return jwt.NewKeyring("ephemeral", map[string][]byte{"ephemeral": key})
+	}
+
+	keys, err := jwt.ParseKeys(spec)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.NewKeyring(os.Getenv("GOBID_JWT_KEY_ID"), keys)
+}
+
+// loadCSRFConfig reads the csrf settings. Protection is on unless
+// GOBID_CSRF_ENABLED is "false", with the 32 byte GOBID_CSRF_KEY, or a
+// random key that only suits a single instance. The cookie is only sent over
+// HTTPS unless GOBID_CSRF_SECURE is "false", for local development.
+// GOBID_TRUSTED_ORIGINS lists the origins of front ends on other hosts,
+// separated by commas.
+func loadCSRFConfig() (api.CSRFConfig, error) {
+	config := api.CSRFConfig{
+		Enabled: os.Getenv("GOBID_CSRF_ENABLED") != "false",
+		Key:     []byte(os.Getenv("GOBID_CSRF_KEY")),
+		Secure:  os.Getenv("GOBID_CSRF_SECURE") != "false",
+	}
+
+	config.TrustedOrigins = splitList(os.Getenv("GOBID_TRUSTED_ORIGINS"))
+
+	if !config.Enabled {
+		slog.Warn("CSRF protection is disabled")
+		return config, nil
+	}
+
+	if len(config.Key) == 0 {
+		slog.Warn("GOBID_CSRF_KEY is not set, signing csrf tokens with a random key")
+		config.Key = make([]byte, 32)
+		if _, err := rand.Read(config.Key); err != nil {
+			return api.CSRFConfig{}, err
+		}
+	}
+	if len(config.Key) != 32 {
+		return api.CSRFConfig{}, fmt.Errorf("GOBID_CSRF_KEY must be 32 bytes, got %d", len(config.Key))
+	}
+	return config, nil
+}
+
+// loadWsAllowedOrigins reads the origins allowed to open websocket
+// connections from GOBID_WS_ALLOWED_ORIGINS, separated by commas, defaulting
+// to the csrf trusted origins.
+func loadWsAllowedOrigins(trusted []string) []string {
+	origins := splitList(os.Getenv("GOBID_WS_ALLOWED_ORIGINS"))
+	if len(origins) == 0 {
+		return trusted
+	}
+	return origins
+}
+
+// loadTrustedProxies reads the addresses of the load balancers and reverse
+// proxies in front of the API from GOBID_TRUSTED_PROXIES, as IP addresses or
+// CIDR ranges separated by commas. Requests from them are counted by the
+// client address in X-Forwarded-For.
+func loadTrustedProxies() ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, item := range splitList(os.Getenv("GOBID_TRUSTED_PROXIES")) {
+		if addr, err := netip.ParseAddr(item); err == nil {
+			addr = addr.Unmap()
+			proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("GOBID_TRUSTED_PROXIES: %q is not an address or a range", item)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadIdentityProviders configures the OpenID Connect providers named in
+// GOBID_OIDC_PROVIDERS, separated by commas. Each one is read from
+// GOBID_OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and _REDIRECT_URL.
+func loadIdentityProviders(ctx context.Context) (map[string]identity.Provider, error) {
+	providers := make(map[string]identity.Provider)
+
+	names := os.Getenv("GOBID_OIDC_PROVIDERS")
+	if names == "" {
+		return providers, nil
+	}
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		prefix := "GOBID_OIDC_" + strings.ToUpper(name) + "_"
+
+		provider, err := identity.NewOIDCProvider(ctx, identity.OIDCConfig{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		providers[name] = provider
+	}
+	return providers, nil
+}
@@ -0,0 +1,34 @@
+package access
+
+// Role grants access to the moderation routes. Every role can do what the
+// roles below it can.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+var roleRanks = map[Role]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Includes reports whether the role can do what other can.
+func (r Role) Includes(other Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[other]
+}
+
+// Outranks reports whether the role can moderate users with the other role.
+func (r Role) Outranks(other Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank > roleRanks[other]
+}
@@ -0,0 +1,33 @@
+// Package access holds the user roles and api token scopes, shared by the
+// request validation in usecase and the checks in services and api.
+package access
+
+import "slices"
+
+// Scopes an api token can be granted. Browser sessions can do everything.
+const (
+	ScopeProfileRead    = "profile:read"
+	ScopeProfileWrite   = "profile:write"
+	ScopeWatchlistRead  = "watchlist:read"
+	ScopeWatchlistWrite = "watchlist:write"
+	ScopeProductsRead   = "products:read"
+	ScopeProductsWrite  = "products:write"
+	ScopeBidsRead       = "bids:read"
+	ScopeBidsWrite      = "bids:write"
+)
+
+var Scopes = []string{
+	ScopeProfileRead,
+	ScopeProfileWrite,
+	ScopeWatchlistRead,
+	ScopeWatchlistWrite,
+	ScopeProductsRead,
+	ScopeProductsWrite,
+	ScopeBidsRead,
+	ScopeBidsWrite,
+}
+
+// ValidScope reports whether scope can be granted to an api token.
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
+	"github.com/nathancamolez-dev/go-bid/internal/usecase/user"
+)
+
+// handleExportAccount sends the personal data of the user as a JSON
+// document, or with ?format=zip as an archive with one file per section.
+func (api *Api) handleExportAccount(w http.ResponseWriter, r *http.Request) {
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "format must be json or zip",
+		})
+		return
+	}
+
+	export, err := api.UserService.ExportAccount(r.Context(), userId, api.Sessions.Token(r.Context()))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	name := "gobid-export-" + export.ExportedAt.Format("20060102")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if format != "zip" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".json"))
+		_ = jsonutils.EncodeJson(w, r, http.StatusOK, export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".zip"))
+	w.WriteHeader(http.StatusOK)
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", export.Profile},
+		{"products.json", export.Products},
+		{"bids.json", export.Bids},
+		{"sessions.json", export.Sessions},
+		{"api_tokens.json", export.ApiTokens},
+		{"identities.json", export.Identities},
+		{"login_history.json", export.LoginHistory},
+		{"watchlist.json", export.Watchlist},
+		{"notifications.json", export.Notifications},
+		{"feedback_given.json", export.FeedbackGiven},
+		{"credit_limits.json", export.CreditLimits},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return
+		}
+	}
+	_ = archive.Close()
+}
+
+// handleDeleteAccount schedules the account for deletion and logs the user
+// out. The account can be restored with handleRestoreAccount until then.
+func (api *Api) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.DeleteAccountReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	loggedInAt := time.Unix(api.Sessions.GetInt64(r.Context(), "AuthenticatedAt"), 0)
+	scheduledFor, err := api.UserService.DeleteAccount(r.Context(), userId, data.Password, loggedInAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+				"error": "password is incorrect",
+			})
+		case errors.Is(err, services.ErrReauthenticationRequired):
+			jsonutils.EncodeJson(w, r, http.StatusUnauthorized, map[string]any{
+				"error": "log in again through your identity provider, then retry within 10 minutes",
+			})
+		case errors.Is(err, services.ErrActiveAuctions):
+			jsonutils.EncodeJson(w, r, http.StatusConflict, map[string]any{
+				"error": "wait for the auctions you sell or lead to end",
+			})
+		default:
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "unexpected internal server error",
+			})
+		}
+		return
+	}
+
+	_ = api.Sessions.Destroy(r.Context())
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message":       "your account will be deleted, log in through the restore route to keep it",
+		"scheduled_for": scheduledFor.Truncate(time.Second),
+	})
+}
+
+// handleRestoreAccount cancels a scheduled deletion and logs the user in.
+func (api *Api) handleRestoreAccount(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.LoginUserReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	id, err := api.UserService.RestoreAccount(r.Context(), data.Email, data.Password, api.loginIP(r))
+	if err != nil {
+		var locked services.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			w.Header().Set("Retry-After", strconv.Itoa(int(locked.RetryAfter.Seconds())+1))
+			jsonutils.EncodeJson(w, r, http.StatusTooManyRequests, map[string]any{
+				"error": "Too many failed login attempts, try again later",
+			})
+		case errors.Is(err, services.ErrInvalidCredentials):
+			jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+				"error": "Invalid email or password",
+			})
+		case errors.Is(err, services.ErrAccountSuspended):
+			writeSuspended(w, r, err)
+		default:
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "unexpected internal server error",
+			})
+		}
+		return
+	}
+
+	twoFactor, err := api.UserService.TwoFactorEnabled(r.Context(), id)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if twoFactor {
+		if err := api.startTwoFactorLogin(r, id); err != nil {
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "unexpected internal server error",
+			})
+			return
+		}
+
+		_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+			"message":             "account restored, enter your two factor code",
+			"two_factor_required": true,
+		})
+		return
+	}
+
+	if err := api.logIn(r, id); err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "account restored",
+	})
+}
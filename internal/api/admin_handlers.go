@@ -0,0 +1,281 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
+	"github.com/nathancamolez-dev/go-bid/internal/usecase/admin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+// pagination reads the limit and offset query parameters.
+func pagination(r *http.Request) (int32, int32, bool) {
+	limit, offset := int64(defaultPageSize), int64(0)
+
+	var err error
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.ParseInt(raw, 10, 32)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return 0, 0, false
+		}
+	}
+	if raw := r.URL.Query().Get("offset"); raw != "" {
+		offset, err = strconv.ParseInt(raw, 10, 32)
+		if err != nil || offset < 0 {
+			return 0, 0, false
+		}
+	}
+	return int32(limit), int32(offset), true
+}
+
+func (api *Api) handleAdminListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := pagination(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "limit must be between 1 and 100 and offset must not be negative",
+		})
+		return
+	}
+
+	users, err := api.AdminService.SearchUsers(r.Context(), r.URL.Query().Get("q"), limit, offset)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"users": users,
+	})
+}
+
+func (api *Api) handleAdminSetRole(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid uuid",
+		})
+		return
+	}
+
+	data, problems, err := jsonutils.DecodeValidJson[admin.SetRoleReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	actorId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.AdminService.SetRole(r.Context(), actorId, userId, data.Role); err != nil {
+		api.writeAdminError(w, r, err)
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "role updated",
+	})
+}
+
+func (api *Api) handleAdminSuspendUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid uuid",
+		})
+		return
+	}
+
+	data, problems, err := jsonutils.DecodeValidJson[admin.SuspendUserReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	actorId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	duration := time.Duration(data.DurationHours) * time.Hour
+	err = api.AdminService.SuspendUser(r.Context(), actorId, userId, data.Reason, duration)
+	if err != nil {
+		api.writeAdminError(w, r, err)
+		return
+	}
+	api.AuctionLobby.DisconnectUser(r.Context(), userId, "your account has been suspended: "+data.Reason)
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "user suspended",
+	})
+}
+
+func (api *Api) handleAdminUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid uuid",
+		})
+		return
+	}
+
+	actorId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.AdminService.UnsuspendUser(r.Context(), actorId, userId); err != nil {
+		api.writeAdminError(w, r, err)
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "user unsuspended",
+	})
+}
+
+func (api *Api) handleAdminCloseAuction(w http.ResponseWriter, r *http.Request) {
+	productId, err := uuid.Parse(chi.URLParam(r, "product_id"))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid uuid",
+		})
+		return
+	}
+
+	data, problems, err := jsonutils.DecodeValidJson[admin.CloseAuctionReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	actorId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.AdminService.CloseAuction(r.Context(), actorId, productId, data.Reason); err != nil {
+		api.writeAdminError(w, r, err)
+		return
+	}
+	api.AuctionLobby.CloseRoom(r.Context(), productId)
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "auction closed",
+	})
+}
+
+func (api *Api) handleAdminGetBidHistory(w http.ResponseWriter, r *http.Request) {
+	productId, err := uuid.Parse(chi.URLParam(r, "product_id"))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid uuid",
+		})
+		return
+	}
+
+	bids, err := api.AdminService.GetBidHistory(r.Context(), productId)
+	if err != nil {
+		api.writeAdminError(w, r, err)
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"bids": bids,
+	})
+}
+
+func (api *Api) writeAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		jsonutils.EncodeJson(w, r, http.StatusNotFound, map[string]any{
+			"error": "user not found",
+		})
+	case errors.Is(err, services.ErrProductNotFound):
+		jsonutils.EncodeJson(w, r, http.StatusNotFound, map[string]any{
+			"error": "product not found",
+		})
+	case errors.Is(err, services.ErrInsufficientRole):
+		jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+			"error": "you can only moderate users with a lower role than yours",
+		})
+	case errors.Is(err, services.ErrAuctionClosed):
+		jsonutils.EncodeJson(w, r, http.StatusConflict, map[string]any{
+			"error": "the auction is already closed",
+		})
+	default:
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+	}
+}
+
+func (api *Api) handleAdminSetCreditLimit(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid uuid",
+		})
+		return
+	}
+
+	data, problems, err := jsonutils.DecodeValidJson[admin.SetCreditLimitReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	var amount *money.Money
+	if data.Amount != nil {
+		limit, _ := data.Amount.Money(data.Currency)
+		amount = &limit
+	}
+
+	if err := api.AdminService.SetCreditLimit(r.Context(), userId, data.Currency, amount); err != nil {
+		api.writeAdminError(w, r, err)
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "credit limit updated",
+	})
+}
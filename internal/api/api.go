@@ -1,19 +1,33 @@
 package api
 
 import (
+	"net/netip"
+
 	"github.com/alexedwards/scs/v2"
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 
+	"github.com/nathancamolez-dev/go-bid/internal/identity"
 	"github.com/nathancamolez-dev/go-bid/internal/services"
 )
 
 type Api struct {
-	Router         *chi.Mux
-	UserService    services.UserServices
-	ProductService services.ProductService
-	BidsServices   services.BidsServices
-	Sessions       *scs.SessionManager
-	WsUpgrader     websocket.Upgrader
-	AuctionLobby   services.AuctionLobby
+	Router            *chi.Mux
+	UserService       services.UserServices
+	ProductService    services.ProductService
+	BidsServices      services.BidsServices
+	WatchlistService  services.WatchlistService
+	FeedbackService   services.FeedbackService
+	SessionService    services.SessionService
+	ApiTokenService   services.ApiTokenService
+	AuthTokenService  services.AuthTokenService
+	AdminService      services.AdminService
+	WsTicketService   services.WsTicketService
+	IdentityProviders map[string]identity.Provider
+	Sessions          *scs.SessionManager
+	CSRF              CSRFConfig
+	WsUpgrader        websocket.Upgrader
+	WsAllowedOrigins  []string
+	TrustedProxies    []netip.Prefix
+	AuctionLobby      services.AuctionLobby
 }
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/gob"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jwt"
+	"github.com/nathancamolez-dev/go-bid/internal/mailer"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
+)
+
+// testOrigin is the front end the test api trusts, for csrf and websockets.
+const testOrigin = "https://app.example.com"
+
+// newTestApi wires the routes the way cmd/api does, with sessions in memory
+// and mails logged. Without a pool, only requests that stop before the
+// database can be made.
+func newTestApi(t *testing.T, pool *pgxpool.Pool) *Api {
+	t.Helper()
+	gob.Register(uuid.UUID{})
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	keys, err := jwt.NewKeyring("test", map[string][]byte{"test": key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bidsServices := services.NewBidsServices(pool)
+	api := &Api{
+		Router:           chi.NewRouter(),
+		UserService:      services.NewUserService(pool, mailer.LogMailer{}),
+		ProductService:   services.NewProductService(pool),
+		BidsServices:     bidsServices,
+		WatchlistService: services.NewWatchlistService(pool),
+		FeedbackService:  services.NewFeedbackService(pool),
+		SessionService:   services.NewSessionService(pool),
+		ApiTokenService:  services.NewApiTokenService(pool),
+		AuthTokenService: services.NewAuthTokenService(pool, keys),
+		AdminService:     services.NewAdminService(pool),
+		WsTicketService:  services.NewWsTicketService(pool),
+		Sessions:         scs.New(),
+		CSRF: CSRFConfig{
+			Enabled:        true,
+			Key:            key,
+			TrustedOrigins: []string{testOrigin},
+		},
+		WsAllowedOrigins: []string{testOrigin},
+		AuctionLobby: services.AuctionLobby{
+			Rooms:        make(map[uuid.UUID]*services.AuctionRoom),
+			InstanceId:   uuid.New(),
+			PubSub:       services.NewLocalPubSub(),
+			Locker:       services.LocalRoomLocker{},
+			BidsServices: bidsServices,
+		},
+	}
+	api.WsUpgrader = websocket.Upgrader{CheckOrigin: api.CheckOrigin}
+	api.BindRoutes()
+	return api
+}
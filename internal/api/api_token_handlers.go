@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
+	"github.com/nathancamolez-dev/go-bid/internal/usecase/user"
+)
+
+func (api *Api) handleCreateApiToken(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.CreateApiTokenReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	expiresIn := time.Duration(data.ExpiresInDays) * 24 * time.Hour
+	token, apiToken, err := api.ApiTokenService.CreateApiToken(
+		r.Context(),
+		userId,
+		data.Name,
+		data.Scopes,
+		expiresIn,
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrTooManyApiTokens) {
+			jsonutils.EncodeJson(w, r, http.StatusConflict, map[string]any{
+				"error": "too many api tokens, revoke one first",
+			})
+			return
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	// The token can't be recovered later, only its hash is stored.
+	_ = jsonutils.EncodeJson(w, r, http.StatusCreated, map[string]any{
+		"token":     token,
+		"api_token": apiToken,
+	})
+}
+
+func (api *Api) handleListApiTokens(w http.ResponseWriter, r *http.Request) {
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	tokens, err := api.ApiTokenService.ListApiTokens(r.Context(), userId)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"api_tokens": tokens,
+	})
+}
+
+func (api *Api) handleRevokeApiToken(w http.ResponseWriter, r *http.Request) {
+	tokenId, err := uuid.Parse(chi.URLParam(r, "token_id"))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid uuid",
+		})
+		return
+	}
+
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.ApiTokenService.RevokeApiToken(r.Context(), userId, tokenId); err != nil {
+		if errors.Is(err, services.ErrApiTokenNotFound) {
+			jsonutils.EncodeJson(w, r, http.StatusNotFound, map[string]any{
+				"error": "api token not found",
+			})
+			return
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "api token revoked",
+	})
+}
@@ -2,8 +2,8 @@ package api
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -25,7 +25,7 @@ func (api *Api) handleSubscribeToAuction(w http.ResponseWriter, r *http.Request)
 
 	}
 
-	_, err = api.ProductService.GetProductById(r.Context(), productId)
+	product, err := api.ProductService.GetProductById(r.Context(), productId)
 	if err != nil {
 		if errors.Is(err, services.ErrProductNotFound) {
 
@@ -41,37 +41,59 @@ func (api *Api) handleSubscribeToAuction(w http.ResponseWriter, r *http.Request)
 
 	}
 
-	userId, ok := api.Sessions.Get(r.Context(), "AuthenticateUserId").(uuid.UUID)
+	userId, ok := authenticatedUserId(r)
 	if !ok {
 		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
 			"message": "unexpected internal server error",
 		})
+		return
+	}
 
+	if !product.AuctionEnd.After(time.Now()) {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"message": "the auction has ended",
+		})
+		return
 	}
 
-	conn, err := api.WsUpgrader.Upgrade(w, r, nil)
+	// The room may have been created by another instance, so this one starts
+	// its own copy on the first subscription.
+	room := api.AuctionLobby.OpenRoom(productId, product.Currency, product.AuctionEnd)
+
+	user, err := api.UserService.GetUserById(r.Context(), userId)
 	if err != nil {
 		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
 			"message": "unexpected internal server error",
 		})
+		return
 	}
 
-	fmt.Println("here")
-
-	api.AuctionLobby.Lock()
-	room, ok := api.AuctionLobby.Rooms[productId]
-	api.AuctionLobby.Unlock()
-
-	if !ok {
-		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
-			"message": "the auction has ended",
+	snapshot, err := api.ProductService.GetAuctionSnapshot(r.Context(), product)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"message": "unexpected internal server error",
 		})
+		return
+	}
+
+	conn, err := api.WsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already replied to the client with an HTTP error.
+		return
 	}
 
 	client := services.NewClient(room, conn, userId)
-	fmt.Println(client)
+	if user.DisplayCurrency != nil {
+		client.DisplayCurrency = *user.DisplayCurrency
+	}
+	client.Snapshot = &snapshot
 
-	room.Register <- client
+	select {
+	case room.Register <- client:
+	case <-room.Context.Done():
+		conn.Close()
+		return
+	}
 	go client.ReadEventLoop()
 	go client.WriteEventLoop()
 
@@ -1,13 +1,34 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/alexedwards/scs/v2"
+	"github.com/google/uuid"
 	"github.com/gorilla/csrf"
 
+	"github.com/nathancamolez-dev/go-bid/internal/access"
 	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
 )
 
+type authContextKey struct{}
+
+// authInfo is who made the request. ApiToken is only set for requests
+// authenticated with an api token.
+type authInfo struct {
+	UserId   uuid.UUID
+	ApiToken *services.ApiToken
+}
+
+func authenticatedUserId(r *http.Request) (uuid.UUID, bool) {
+	info, ok := r.Context().Value(authContextKey{}).(authInfo)
+	return info.UserId, ok
+}
+
 func (api *Api) HandleGetCSRFtoken(w http.ResponseWriter, r *http.Request) {
 	token := csrf.Token(r)
 	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
@@ -15,15 +36,226 @@ func (api *Api) HandleGetCSRFtoken(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+var errNoCredentials = errors.New("no credentials")
+
+// authenticator is one of the ways a request can prove who makes it. It
+// returns errNoCredentials when the request doesn't carry its kind of
+// credentials, and services.ErrInvalidToken when they aren't valid.
+type authenticator interface {
+	authenticate(r *http.Request) (authInfo, error)
+}
+
+// AuthMiddleware accepts the session cookie, api tokens and access tokens,
+// the last two sent as "Authorization: Bearer <token>", and websocket
+// tickets on websocket connections.
 func (api *Api) AuthMiddleware(next http.Handler) http.Handler {
+	authenticators := []authenticator{
+		sessionAuthenticator{sessions: api.Sessions, service: api.SessionService},
+		apiTokenAuthenticator{service: api.ApiTokenService},
+		accessTokenAuthenticator{service: api.AuthTokenService},
+		wsTicketAuthenticator{service: api.WsTicketService},
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !api.Sessions.Exists(r.Context(), "AuthenticateUserId") {
-			jsonutils.EncodeJson(w, r, http.StatusUnauthorized, map[string]any{
-				"message": "unauthorized",
+		info, err := authenticate(r, authenticators)
+		if err != nil {
+			if errors.Is(err, errNoCredentials) || errors.Is(err, services.ErrInvalidToken) {
+				if r.Header.Get("Authorization") != "" {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				}
+				jsonutils.EncodeJson(w, r, http.StatusUnauthorized, map[string]any{
+					"message": "unauthorized",
+				})
+				return
+			}
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"message": "unexpected internal server error",
 			})
 			return
 		}
-		next.ServeHTTP(w, r)
 
+		// Suspended users keep their api tokens and access tokens, which
+		// work again once the suspension is over.
+		if err := api.UserService.CheckActive(r.Context(), info.UserId); err != nil {
+			if errors.Is(err, services.ErrAccountSuspended) {
+				writeSuspended(w, r, err)
+				return
+			}
+			if errors.Is(err, services.ErrAccountPendingDeletion) {
+				writePendingDeletion(w, r, err)
+				return
+			}
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"message": "unexpected internal server error",
+			})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), authContextKey{}, info)
+		next.ServeHTTP(w, r.WithContext(ctx))
+
+	})
+}
+
+// writeSuspended tells suspended users why, and until when.
+func writeSuspended(w http.ResponseWriter, r *http.Request, err error) {
+	var suspended services.SuspendedError
+	errors.As(err, &suspended)
+	jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+		"error":           "your account is suspended",
+		"reason":          suspended.Reason,
+		"suspended_until": suspended.Until,
+	})
+}
+
+// writePendingDeletion tells users of accounts scheduled for deletion that
+// they can still restore them.
+func writePendingDeletion(w http.ResponseWriter, r *http.Request, err error) {
+	var pending services.PendingDeletionError
+	errors.As(err, &pending)
+	jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+		"error":         "your account is scheduled for deletion, restore it to log in",
+		"scheduled_for": pending.ScheduledFor,
+	})
+}
+
+func authenticate(r *http.Request, authenticators []authenticator) (authInfo, error) {
+	for _, a := range authenticators {
+		info, err := a.authenticate(r)
+		if !errors.Is(err, errNoCredentials) {
+			return info, err
+		}
+	}
+	return authInfo{}, errNoCredentials
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	return strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+type sessionAuthenticator struct {
+	sessions *scs.SessionManager
+	service  services.SessionService
+}
+
+func (a sessionAuthenticator) authenticate(r *http.Request) (authInfo, error) {
+	// Bearer credentials are never mixed with the cookie.
+	if r.Header.Get("Authorization") != "" {
+		return authInfo{}, errNoCredentials
+	}
+
+	userId, ok := a.sessions.Get(r.Context(), "AuthenticateUserId").(uuid.UUID)
+	if !ok {
+		return authInfo{}, errNoCredentials
+	}
+
+	// The session may have been revoked from another device.
+	active, err := a.service.CheckSession(r.Context(), a.sessions.Token(r.Context()), userId)
+	if err != nil {
+		return authInfo{}, err
+	}
+	if !active {
+		_ = a.sessions.Destroy(r.Context())
+		return authInfo{}, services.ErrInvalidToken
+	}
+
+	return authInfo{UserId: userId}, nil
+}
+
+type apiTokenAuthenticator struct {
+	service services.ApiTokenService
+}
+
+func (a apiTokenAuthenticator) authenticate(r *http.Request) (authInfo, error) {
+	token, ok := bearerToken(r)
+	if !ok || !services.IsApiToken(token) {
+		return authInfo{}, errNoCredentials
+	}
+
+	userId, apiToken, err := a.service.AuthenticateApiToken(r.Context(), token)
+	if err != nil {
+		return authInfo{}, err
+	}
+	return authInfo{UserId: userId, ApiToken: &apiToken}, nil
+}
+
+type accessTokenAuthenticator struct {
+	service services.AuthTokenService
+}
+
+func (a accessTokenAuthenticator) authenticate(r *http.Request) (authInfo, error) {
+	token, ok := bearerToken(r)
+	if !ok || services.IsApiToken(token) {
+		return authInfo{}, errNoCredentials
+	}
+
+	userId, err := a.service.AuthenticateAccessToken(token)
+	if err != nil {
+		return authInfo{}, err
+	}
+	return authInfo{UserId: userId}, nil
+}
+
+// RequireScope lets api tokens through only when they were granted the
+// scope. Routes without it can only be used with the session cookie.
+func (api *Api) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info, _ := r.Context().Value(authContextKey{}).(authInfo)
+			if info.ApiToken != nil && !info.ApiToken.HasScope(scope) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+				jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+					"message": "the api token lacks the " + scope + " scope",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRole lets through users whose role includes role. It goes after
+// AuthMiddleware.
+func (api *Api) RequireRole(role access.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userId, ok := authenticatedUserId(r)
+			if !ok {
+				jsonutils.EncodeJson(w, r, http.StatusUnauthorized, map[string]any{
+					"message": "unauthorized",
+				})
+				return
+			}
+
+			userRole, err := api.AdminService.GetRole(r.Context(), userId)
+			if err != nil {
+				jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+					"message": "unexpected internal server error",
+				})
+				return
+			}
+			if !userRole.Includes(role) {
+				jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+					"message": "forbidden",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DenyApiTokens keeps api tokens away from account management and
+// moderation.
+func (api *Api) DenyApiTokens(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, _ := r.Context().Value(authContextKey{}).(authInfo)
+		if info.ApiToken != nil {
+			jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+				"message": "this route cannot be used with api tokens",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
 }
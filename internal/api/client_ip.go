@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientAddr returns the IP address of the client. Requests from
+// TrustedProxies are taken to be forwarded for the address X-Forwarded-For
+// names last, skipping the trusted proxies in front of it. known is false
+// when a trusted proxy didn't say whom it forwarded for, and the address is
+// then the proxy's own.
+func (api *Api) clientAddr(r *http.Request) (ip string, known bool) {
+	remote, ok := parseAddr(r.RemoteAddr)
+	if !ok {
+		return r.RemoteAddr, false
+	}
+	if !api.trustedProxy(remote) {
+		return remote.String(), true
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseAddr(strings.TrimSpace(hops[i]))
+		if !ok {
+			// Entries left of an invalid one can't be told apart from the
+			// ones a client made up.
+			return remote.String(), false
+		}
+		client = hop
+		if !api.trustedProxy(hop) {
+			break
+		}
+	}
+	return client.String(), true
+}
+
+// clientIP is the address requests are counted by, the proxy's when the
+// client's is unknown.
+func (api *Api) clientIP(r *http.Request) string {
+	ip, _ := api.clientAddr(r)
+	return ip
+}
+
+// loginIP is the address failed logins back off by, empty when the client's
+// is unknown, as the proxy's would lock out every user behind it.
+func (api *Api) loginIP(r *http.Request) string {
+	if ip, known := api.clientAddr(r); known {
+		return ip
+	}
+	return ""
+}
+
+func (api *Api) trustedProxy(addr netip.Addr) bool {
+	for _, prefix := range api.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAddr reads an address with or without a port.
+func parseAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
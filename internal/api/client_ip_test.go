@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/nathancamolez-dev/go-bid/internal/ratelimit"
+)
+
+func newProxiedApi() *Api {
+	return &Api{TrustedProxies: []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}}
+}
+
+func TestClientAddr(t *testing.T) {
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		wantIP    string
+		wantKnown bool
+	}{
+		{"direct", "203.0.113.7:51000", nil, "203.0.113.7", true},
+		{"direct ipv6", "[2001:db8::1]:51000", nil, "2001:db8::1", true},
+		{"direct ignores the header", "203.0.113.7:51000", []string{"198.51.100.1"}, "203.0.113.7", true},
+		{"proxied", "10.0.0.2:51000", []string{"198.51.100.1"}, "198.51.100.1", true},
+		{"proxied ipv6", "[fd00::2]:51000", []string{"2001:db8::5"}, "2001:db8::5", true},
+		{"proxied through several proxies", "10.0.0.2:51000", []string{"198.51.100.1, 10.0.0.3"}, "198.51.100.1", true},
+		{"proxied over several headers", "10.0.0.2:51000", []string{"198.51.100.1", "10.0.0.3"}, "198.51.100.1", true},
+		// Clients can put anything at the start of the header, only the
+		// entries the proxies added are believed.
+		{"proxied with a forged entry", "10.0.0.2:51000", []string{"192.0.2.66, 198.51.100.1"}, "198.51.100.1", true},
+		{"proxied without the header", "10.0.0.2:51000", nil, "10.0.0.2", false},
+		{"proxied with an invalid entry", "10.0.0.2:51000", []string{"not-an-ip"}, "10.0.0.2", false},
+	}
+
+	api := newProxiedApi()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+
+			ip, known := api.clientAddr(r)
+			if ip != tt.wantIP || known != tt.wantKnown {
+				t.Errorf("got (%q, %v), want (%q, %v)", ip, known, tt.wantIP, tt.wantKnown)
+			}
+		})
+	}
+}
+
+func TestRateLimitByIPBehindAProxy(t *testing.T) {
+	api := newProxiedApi()
+	limited := api.RateLimit(ratelimit.Limit{Requests: 1, Window: time.Minute}, api.rateLimitByIP)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	request := func(remote, forwarded string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remote
+		if forwarded != "" {
+			r.Header.Set("X-Forwarded-For", forwarded)
+		}
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Clients behind the proxy each have their own bucket.
+	if got := request("10.0.0.2:1", "198.51.100.1"); got != http.StatusOK {
+		t.Fatalf("first proxied client: got status %d, want %d", got, http.StatusOK)
+	}
+	if got := request("10.0.0.2:2", "198.51.100.2"); got != http.StatusOK {
+		t.Fatalf("second proxied client: got status %d, want %d", got, http.StatusOK)
+	}
+	if got := request("10.0.0.3:1", "198.51.100.1"); got != http.StatusTooManyRequests {
+		t.Fatalf("first proxied client again: got status %d, want %d", got, http.StatusTooManyRequests)
+	}
+
+	// Direct clients can't get a new bucket by sending the header.
+	if got := request("203.0.113.7:1", "198.51.100.3"); got != http.StatusOK {
+		t.Fatalf("direct client: got status %d, want %d", got, http.StatusOK)
+	}
+	if got := request("203.0.113.7:1", "198.51.100.4"); got != http.StatusTooManyRequests {
+		t.Fatalf("direct client with another header: got status %d, want %d", got, http.StatusTooManyRequests)
+	}
+}
+
+func TestLoginIPIsEmptyWhenTheClientIsUnknown(t *testing.T) {
+	api := newProxiedApi()
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.RemoteAddr = "10.0.0.2:51000"
+	if got := api.loginIP(r); got != "" {
+		t.Errorf("without X-Forwarded-For: got %q, want none", got)
+	}
+
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	if got := api.loginIP(r); got != "198.51.100.1" {
+		t.Errorf("with X-Forwarded-For: got %q, want %q", got, "198.51.100.1")
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/websocket"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+)
+
+// CSRFConfig configures the protection of cookie authenticated requests.
+// Key must be 32 bytes. TrustedOrigins are the origins of front ends served
+// from another host, like "https://app.example.com".
+type CSRFConfig struct {
+	Enabled        bool
+	Key            []byte
+	Secure         bool
+	TrustedOrigins []string
+}
+
+// CSRFMiddleware requires the token from /csrftoken in the X-CSRF-Token
+// header of state changing requests. Requests with bearer credentials are
+// exempt, since browsers never attach them on their own, and so are
+// websocket upgrades, whose Origin is checked by WebsocketUpgrade instead.
+func (api *Api) CSRFMiddleware(next http.Handler) http.Handler {
+	if !api.CSRF.Enabled {
+		return next
+	}
+
+	hosts := make([]string, 0, len(api.CSRF.TrustedOrigins))
+	for _, origin := range api.CSRF.TrustedOrigins {
+		if u, err := url.Parse(origin); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+
+	protect := csrf.Protect(api.CSRF.Key,
+		csrf.Secure(api.CSRF.Secure),
+		csrf.Path("/"),
+		csrf.SameSite(csrf.SameSiteLaxMode),
+		csrf.TrustedOrigins(hosts),
+		csrf.ErrorHandler(http.HandlerFunc(handleCSRFFailure)),
+	)(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || websocket.IsWebSocketUpgrade(r) {
+			r = csrf.UnsafeSkipCheck(r)
+		}
+		protect.ServeHTTP(w, r)
+	})
+}
+
+func handleCSRFFailure(w http.ResponseWriter, r *http.Request) {
+	jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+		"error": "invalid or missing csrf token",
+	})
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// csrfProtectedRoutes are state changing routes behind CSRFMiddleware, with
+// the status they answer once the csrf check passes. None of them reaches
+// the database: the login body is invalid and the others need a session.
+var csrfProtectedRoutes = []struct {
+	method, path string
+	passed       int
+}{
+	{http.MethodPost, "/api/v1/users/login", http.StatusUnprocessableEntity},
+	{http.MethodPost, "/api/v1/products/", http.StatusUnauthorized},
+	{http.MethodDelete, "/api/v1/users/me", http.StatusUnauthorized},
+}
+
+func newCSRFTestClient(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Jar: jar}
+}
+
+// fetchCSRFToken gets a token from /csrftoken, leaving its cookie in the
+// client's jar.
+func fetchCSRFToken(t *testing.T, client *http.Client, server *httptest.Server) string {
+	t.Helper()
+	res, err := client.Get(server.URL + "/api/v1/csrftoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.CSRFToken == "" {
+		t.Fatal("csrftoken returned an empty token")
+	}
+	return body.CSRFToken
+}
+
+func do(t *testing.T, client *http.Client, method, url string, header http.Header) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res.StatusCode
+}
+
+func TestCSRFRejectsRequestsWithoutAToken(t *testing.T) {
+	server := httptest.NewServer(newTestApi(t, nil).Router)
+	defer server.Close()
+
+	for _, route := range csrfProtectedRoutes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			// Neither the cookie nor the header.
+			client := newCSRFTestClient(t)
+			if got := do(t, client, route.method, server.URL+route.path, nil); got != http.StatusForbidden {
+				t.Errorf("without cookie: got status %d, want %d", got, http.StatusForbidden)
+			}
+
+			// The cookie a cross site form would carry, but no header.
+			fetchCSRFToken(t, client, server)
+			if got := do(t, client, route.method, server.URL+route.path, nil); got != http.StatusForbidden {
+				t.Errorf("without header: got status %d, want %d", got, http.StatusForbidden)
+			}
+
+			header := http.Header{"X-Csrf-Token": {"not-the-token"}}
+			if got := do(t, client, route.method, server.URL+route.path, header); got != http.StatusForbidden {
+				t.Errorf("with a wrong token: got status %d, want %d", got, http.StatusForbidden)
+			}
+		})
+	}
+}
+
+func TestCSRFAcceptsRequestsWithTheToken(t *testing.T) {
+	server := httptest.NewServer(newTestApi(t, nil).Router)
+	defer server.Close()
+
+	for _, route := range csrfProtectedRoutes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			client := newCSRFTestClient(t)
+			header := http.Header{"X-Csrf-Token": {fetchCSRFToken(t, client, server)}}
+			if got := do(t, client, route.method, server.URL+route.path, header); got != route.passed {
+				t.Errorf("got status %d, want %d", got, route.passed)
+			}
+		})
+	}
+}
+
+func TestCSRFExemptsBearerRequests(t *testing.T) {
+	server := httptest.NewServer(newTestApi(t, nil).Router)
+	defer server.Close()
+
+	// The token is invalid, so authenticated routes go past the csrf check
+	// to be turned down by AuthMiddleware.
+	header := http.Header{"Authorization": {"Bearer not-a-token"}}
+	for _, route := range csrfProtectedRoutes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			got := do(t, newCSRFTestClient(t), route.method, server.URL+route.path, header)
+			if got != route.passed {
+				t.Errorf("got status %d, want %d", got, route.passed)
+			}
+		})
+	}
+}
+
+func TestWebsocketUpgradeChecksOrigin(t *testing.T) {
+	server := httptest.NewServer(newTestApi(t, nil).Router)
+	defer server.Close()
+
+	url := server.URL + "/api/v1/products/ws/subscribe/00000000-0000-0000-0000-000000000000"
+	upgrade := func(origin string) http.Header {
+		return http.Header{
+			"Connection":            {"Upgrade"},
+			"Upgrade":               {"websocket"},
+			"Sec-Websocket-Version": {"13"},
+			"Sec-Websocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+			"Origin":                {origin},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		origin string
+		want   int
+	}{
+		{"other site", "https://evil.example.com", http.StatusForbidden},
+		// Allowed origins go on to AuthMiddleware, without credentials here.
+		{"allowed origin", testOrigin, http.StatusUnauthorized},
+		{"same host", server.URL, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := do(t, newCSRFTestClient(t), http.MethodGet, url, upgrade(tt.origin))
+			if got != tt.want {
+				t.Errorf("got status %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
+	"github.com/nathancamolez-dev/go-bid/internal/usecase/product"
+)
+
+func (api *Api) handleLeaveFeedback(w http.ResponseWriter, r *http.Request) {
+	productId, err := uuid.Parse(chi.URLParam(r, "product_id"))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid uuid",
+		})
+		return
+	}
+
+	data, problems, err := jsonutils.DecodeValidJson[product.LeaveFeedbackReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	err = api.FeedbackService.LeaveFeedback(r.Context(), productId, userId, data.Rating, data.Comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrProductNotFound):
+			jsonutils.EncodeJson(w, r, http.StatusNotFound, map[string]any{
+				"error": "product not found",
+			})
+		case errors.Is(err, services.ErrNotAuctionParty):
+			jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrAuctionNotEnded),
+			errors.Is(err, services.ErrNotSold),
+			errors.Is(err, services.ErrFeedbackWindowClosed),
+			errors.Is(err, services.ErrFeedbackAlreadyLeft):
+			jsonutils.EncodeJson(w, r, http.StatusConflict, map[string]any{
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrAccountSuspended):
+			writeSuspended(w, r, err)
+		default:
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "unexpected internal server error",
+			})
+		}
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusCreated, map[string]any{
+		"message": "feedback saved",
+	})
+}
+
+func (api *Api) handleListFeedback(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid uuid",
+		})
+		return
+	}
+
+	limit, offset, ok := pagination(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "limit must be between 1 and 100 and offset must not be negative",
+		})
+		return
+	}
+
+	reputation, err := api.FeedbackService.GetReputation(r.Context(), userId)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	feedback, err := api.FeedbackService.ListFeedback(r.Context(), userId, limit, offset)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"reputation": reputation,
+		"feedback":   feedback,
+	})
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nathancamolez-dev/go-bid/internal/identity"
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
+)
+
+// providerLoginWindow is how long the user has to log in at the provider.
+const providerLoginWindow = 10 * time.Minute
+
+func (api *Api) handleListIdentityProviders(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(api.IdentityProviders))
+	for name := range api.IdentityProviders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"providers": names,
+	})
+}
+
+// handleStartProviderLogin redirects to the provider, remembering the state,
+// PKCE verifier and nonce of the login in the session. With ?restore=true
+// the login also cancels the deletion of the account.
+func (api *Api) handleStartProviderLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := api.IdentityProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusNotFound, map[string]any{
+			"error": "unknown identity provider",
+		})
+		return
+	}
+
+	login := identity.NewLogin()
+	api.Sessions.Put(r.Context(), "ProviderLoginName", provider.Name())
+	api.Sessions.Put(r.Context(), "ProviderLoginState", login.State)
+	api.Sessions.Put(r.Context(), "ProviderLoginVerifier", login.Verifier)
+	api.Sessions.Put(r.Context(), "ProviderLoginNonce", login.Nonce)
+	api.Sessions.Put(r.Context(), "ProviderLoginAt", time.Now().Unix())
+	api.Sessions.Put(r.Context(), "ProviderLoginRestore", r.URL.Query().Get("restore") == "true")
+
+	http.Redirect(w, r, provider.AuthCodeURL(login.State, login.Verifier, login.Nonce), http.StatusFound)
+}
+
+func (api *Api) handleProviderCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := api.IdentityProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusNotFound, map[string]any{
+			"error": "unknown identity provider",
+		})
+		return
+	}
+
+	// The login can only be completed once.
+	name := api.Sessions.PopString(r.Context(), "ProviderLoginName")
+	state := api.Sessions.PopString(r.Context(), "ProviderLoginState")
+	verifier := api.Sessions.PopString(r.Context(), "ProviderLoginVerifier")
+	nonce := api.Sessions.PopString(r.Context(), "ProviderLoginNonce")
+	startedAt := time.Unix(api.Sessions.GetInt64(r.Context(), "ProviderLoginAt"), 0)
+	api.Sessions.Remove(r.Context(), "ProviderLoginAt")
+	restore := api.Sessions.PopBool(r.Context(), "ProviderLoginRestore")
+
+	if reason := r.URL.Query().Get("error"); reason != "" {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "the provider did not log you in: " + reason,
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	if name != provider.Name() || state == "" ||
+		subtle.ConstantTimeCompare([]byte(state), []byte(query.Get("state"))) != 1 ||
+		time.Since(startedAt) > providerLoginWindow {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid or expired login, start again",
+		})
+		return
+	}
+
+	id, err := provider.Exchange(r.Context(), query.Get("code"), verifier, nonce)
+	if err != nil {
+		slog.Warn("Identity provider login failed", "provider", name, "error", err)
+		jsonutils.EncodeJson(w, r, http.StatusUnauthorized, map[string]any{
+			"error": "the provider login failed",
+		})
+		return
+	}
+
+	login := api.UserService.LoginWithIdentity
+	if restore {
+		login = api.UserService.RestoreAccountWithIdentity
+	}
+
+	userId, err := login(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			jsonutils.EncodeJson(w, r, http.StatusNotFound, map[string]any{
+				"error": "no account is linked to this identity",
+			})
+		case errors.Is(err, services.ErrIdentityEmailUnverified):
+			jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+				"error": "the provider did not verify your email",
+			})
+		case errors.Is(err, services.ErrAccountSuspended):
+			writeSuspended(w, r, err)
+		case errors.Is(err, services.ErrAccountPendingDeletion):
+			writePendingDeletion(w, r, err)
+		case errors.Is(err, services.ErrIdentityEmailTaken),
+			errors.Is(err, services.ErrDuplicatedEmailOrUsername):
+			jsonutils.EncodeJson(w, r, http.StatusConflict, map[string]any{
+				"error": "an account already uses this email, log in with your password",
+			})
+		default:
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "unexpected internal server error",
+			})
+		}
+		return
+	}
+
+	twoFactor, err := api.UserService.TwoFactorEnabled(r.Context(), userId)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if twoFactor {
+		if err := api.startTwoFactorLogin(r, userId); err != nil {
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "unexpected internal server error",
+			})
+			return
+		}
+
+		_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+			"message":             "enter your two factor code",
+			"two_factor_required": true,
+		})
+		return
+	}
+
+	if err := api.logIn(r, userId); err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "!!successfully logged in",
+	})
+}
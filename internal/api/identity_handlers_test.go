@@ -0,0 +1,303 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/nathancamolez-dev/go-bid/internal/identity"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore/pgtest"
+)
+
+// oidcIssuerEnv names the variable holding the issuer of the mock provider
+// of docker-compose.yml, "http://localhost:8081/default". Provider login
+// tests are skipped without it.
+const oidcIssuerEnv = "GOBID_TEST_OIDC_ISSUER"
+
+type providerLoginTest struct {
+	server  *httptest.Server
+	queries *pgstore.Queries
+}
+
+func newProviderLoginTest(t *testing.T) providerLoginTest {
+	t.Helper()
+
+	issuer := os.Getenv(oidcIssuerEnv)
+	if issuer == "" {
+		t.Skip(oidcIssuerEnv + " is not set")
+	}
+	pool := pgtest.NewPool(t)
+
+	api := newTestApi(t, pool)
+	server := httptest.NewServer(api.Router)
+	t.Cleanup(server.Close)
+
+	provider, err := identity.NewOIDCProvider(context.Background(), identity.OIDCConfig{
+		Name:         "mock",
+		IssuerURL:    issuer,
+		ClientID:     "gobid",
+		ClientSecret: "secret",
+		RedirectURL:  server.URL + "/api/v1/auth/providers/mock/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.IdentityProviders = map[string]identity.Provider{"mock": provider}
+
+	return providerLoginTest{server: server, queries: pgstore.New(pool)}
+}
+
+func (lt providerLoginTest) createUser(t *testing.T, name string, verified bool) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+
+	id, err := lt.queries.CreateUser(ctx, pgstore.CreateUserParams{
+		UserName:     name,
+		Email:        name + "@example.com",
+		PasswordHash: []byte("not a hash"),
+		Bio:          "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified {
+		if err := lt.queries.MarkEmailVerified(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return id
+}
+
+// login goes through the provider login of a new client, filling the login
+// form of the mock provider with the subject and claims, and returns the
+// client, holding the session, with the callback's response.
+func (lt providerLoginTest) login(t *testing.T, subject string, claims map[string]any) (*http.Client, int, map[string]any) {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	redirect := func(res *http.Response, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusFound {
+			t.Fatalf("%s: got status %d, want a redirect", res.Request.URL, res.StatusCode)
+		}
+		return res.Header.Get("Location")
+	}
+
+	authURL := redirect(client.Get(lt.server.URL + "/api/v1/auth/providers/mock/login"))
+
+	rawClaims, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callbackURL := redirect(client.PostForm(authURL, url.Values{
+		"username": {subject},
+		"claims":   {string(rawClaims)},
+	}))
+
+	res, err := client.Get(callbackURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var body map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return client, res.StatusCode, body
+}
+
+// me returns the id of the user the client is logged in as, and false when
+// it isn't logged in.
+func (lt providerLoginTest) me(t *testing.T, client *http.Client) (uuid.UUID, bool) {
+	t.Helper()
+
+	res, err := client.Get(lt.server.URL + "/api/v1/users/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusUnauthorized {
+		return uuid.Nil, false
+	}
+
+	var me struct {
+		ID uuid.UUID `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&me); err != nil {
+		t.Fatal(err)
+	}
+	return me.ID, true
+}
+
+func TestProviderLoginCreatesAnAccount(t *testing.T) {
+	lt := newProviderLoginTest(t)
+
+	client, status, body := lt.login(t, "newcomer", map[string]any{
+		"email":              "newcomer@example.com",
+		"email_verified":     true,
+		"preferred_username": "newcomer",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %v", status, http.StatusOK, body)
+	}
+
+	user, err := lt.queries.GetUserByEmail(context.Background(), "newcomer@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.EmailVerifiedAt.Valid {
+		t.Error("the new account's email is not verified")
+	}
+	if id, ok := lt.me(t, client); !ok || id != user.ID {
+		t.Errorf("logged in as %v, want %v", id, user.ID)
+	}
+
+	// The identity stays linked to the account.
+	client, status, _ = lt.login(t, "newcomer", map[string]any{
+		"email":          "newcomer@example.com",
+		"email_verified": true,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("second login: got status %d, want %d", status, http.StatusOK)
+	}
+	if id, _ := lt.me(t, client); id != user.ID {
+		t.Errorf("second login: logged in as %v, want %v", id, user.ID)
+	}
+}
+
+func TestProviderLoginLinksAVerifiedAccount(t *testing.T) {
+	lt := newProviderLoginTest(t)
+	userId := lt.createUser(t, "linked", true)
+
+	client, status, body := lt.login(t, "linked-subject", map[string]any{
+		"email":          "linked@example.com",
+		"email_verified": true,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %v", status, http.StatusOK, body)
+	}
+	if id, ok := lt.me(t, client); !ok || id != userId {
+		t.Errorf("logged in as %v, want %v", id, userId)
+	}
+
+	linked, err := lt.queries.GetUserIdentity(context.Background(), pgstore.GetUserIdentityParams{
+		Provider: "mock",
+		Subject:  "linked-subject",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linked.UserID != userId {
+		t.Errorf("identity linked to %v, want %v", linked.UserID, userId)
+	}
+}
+
+func TestProviderLoginRefusesAnUnverifiedAccount(t *testing.T) {
+	lt := newProviderLoginTest(t)
+	lt.createUser(t, "unverified", false)
+
+	// Whoever registered the email without verifying it could otherwise get
+	// the account of its real owner once they log in with the provider.
+	client, status, body := lt.login(t, "unverified-subject", map[string]any{
+		"email":          "unverified@example.com",
+		"email_verified": true,
+	})
+	if status != http.StatusConflict {
+		t.Fatalf("got status %d, want %d: %v", status, http.StatusConflict, body)
+	}
+	if _, ok := lt.me(t, client); ok {
+		t.Error("the client is logged in")
+	}
+}
+
+func TestProviderLoginAsksForTheSecondFactor(t *testing.T) {
+	lt := newProviderLoginTest(t)
+	ctx := context.Background()
+
+	userId := lt.createUser(t, "twofactor", true)
+	err := lt.queries.SetPendingTotpSecret(ctx, pgstore.SetPendingTotpSecretParams{
+		ID:                userId,
+		TotpPendingSecret: pgtype.Text{String: "JBSWY3DPEHPK3PXP", Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lt.queries.EnableTotp(ctx, pgstore.EnableTotpParams{ID: userId}); err != nil {
+		t.Fatal(err)
+	}
+
+	client, status, body := lt.login(t, "twofactor-subject", map[string]any{
+		"email":          "twofactor@example.com",
+		"email_verified": true,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %v", status, http.StatusOK, body)
+	}
+	if body["two_factor_required"] != true {
+		t.Errorf("got %v, want two_factor_required", body)
+	}
+	if _, ok := lt.me(t, client); ok {
+		t.Error("the client is logged in before the second factor")
+	}
+}
+
+func TestProviderLoginRefusesAnAccountPendingDeletion(t *testing.T) {
+	lt := newProviderLoginTest(t)
+	ctx := context.Background()
+
+	userId := lt.createUser(t, "leaving", true)
+	err := lt.queries.ScheduleAccountDeletion(ctx, pgstore.ScheduleAccountDeletionParams{
+		ID:                   userId,
+		DeletionScheduledFor: pgtype.Timestamptz{Time: time.Now().Add(24 * time.Hour), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, status, body := lt.login(t, "leaving-subject", map[string]any{
+		"email":          "leaving@example.com",
+		"email_verified": true,
+	})
+	if status != http.StatusForbidden {
+		t.Fatalf("got status %d, want %d: %v", status, http.StatusForbidden, body)
+	}
+	if _, ok := lt.me(t, client); ok {
+		t.Error("the client is logged in")
+	}
+
+	// The identity isn't linked, so it can't restore the account either.
+	_, err = lt.queries.GetUserIdentity(ctx, pgstore.GetUserIdentityParams{
+		Provider: "mock",
+		Subject:  "leaving-subject",
+	})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("got %v looking up the identity, want it not linked", err)
+	}
+}
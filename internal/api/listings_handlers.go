@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
+)
+
+func (api *Api) handleGetMyListings(w http.ResponseWriter, r *http.Request) {
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	limit, offset, ok := pagination(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "limit must be between 1 and 100 and offset must not be negative",
+		})
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !slices.Contains(services.ListingStatuses, status) {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "status must be one of " + strings.Join(services.ListingStatuses, ", "),
+		})
+		return
+	}
+
+	dashboard, err := api.ProductService.GetSellerDashboard(r.Context(), userId, status, limit, offset)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, dashboard)
+}
+
+func (api *Api) handleGetMyBids(w http.ResponseWriter, r *http.Request) {
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	limit, offset, ok := pagination(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "limit must be between 1 and 100 and offset must not be negative",
+		})
+		return
+	}
+
+	state := r.URL.Query().Get("state")
+	if state != "" && !slices.Contains(services.BidStates, state) {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "state must be one of " + strings.Join(services.BidStates, ", "),
+		})
+		return
+	}
+
+	bids, err := api.BidsServices.ListBidActivity(r.Context(), userId, state, limit, offset)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, bids)
+}
+
+func (api *Api) handleGetMyCredit(w http.ResponseWriter, r *http.Request) {
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	credit, err := api.UserService.GetCredit(r.Context(), userId)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, credit)
+}
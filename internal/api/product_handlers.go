@@ -1,11 +1,9 @@
 package api
 
 import (
-	"context"
+	"errors"
 	"net/http"
 
-	"github.com/google/uuid"
-
 	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
 	"github.com/nathancamolez-dev/go-bid/internal/services"
 	"github.com/nathancamolez-dev/go-bid/internal/usecase/product"
@@ -27,40 +25,44 @@ func (api *Api) handleCreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, ok := api.Sessions.Get(r.Context(), "AuthenticateUserId").(uuid.UUID)
+	userID, ok := authenticatedUserId(r)
 	if !ok {
 		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
 			"error": "Unexpected internal server error",
 		})
 	}
 
+	// Already checked by Valid.
+	baseprice, _ := data.Baseprice.Money(data.Currency)
+
 	productId, err := api.ProductService.CreateProduct(r.Context(),
 		userID,
 		data.ProductName,
 		data.Description,
-		data.Baseprice,
+		baseprice,
+		data.Currency,
 		data.AuctionEnd,
 	)
 	if err != nil {
+		if errors.Is(err, services.ErrEmailNotVerified) {
+			jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+				"error": "verify your email before creating products",
+			})
+			return
+		}
 		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
 			"error": "failed to create product",
 		})
 		return
 	}
 
-	ctx, _ := context.WithDeadline(context.Background(), data.AuctionEnd)
-
-	auctionRoom := services.NewAuctionRoom(ctx, productId, api.BidsServices)
-
-	go auctionRoom.Run()
-
-	api.AuctionLobby.Lock()
-	api.AuctionLobby.Rooms[productId] = auctionRoom
-	api.AuctionLobby.Unlock()
+	api.AuctionLobby.OpenRoom(productId, data.Currency, data.AuctionEnd)
 
 	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
 		"message":    "Sucessfully created product",
 		"product_id": productId,
+		"baseprice":  baseprice.Decimal(data.Currency),
+		"currency":   data.Currency,
 	})
 
 }
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/ratelimit"
+	"github.com/nathancamolez-dev/go-bid/internal/usecase/user"
+)
+
+// rateLimitByIP counts the requests of each IP address.
+func (api *Api) rateLimitByIP(r *http.Request) string {
+	return "ip:" + api.clientIP(r)
+}
+
+// rateLimitByUser counts the requests of each user, going after
+// AuthMiddleware, and of each IP address for anonymous requests.
+func (api *Api) rateLimitByUser(r *http.Request) string {
+	if userId, ok := authenticatedUserId(r); ok {
+		return "user:" + userId.String()
+	}
+	return api.rateLimitByIP(r)
+}
+
+// RateLimit refuses requests over the limit with a 429, counting them by
+// the key. Responses carry the RateLimit-* headers of the tightest limit
+// the request went through.
+func (api *Api) RateLimit(limit ratelimit.Limit, key func(*http.Request) string) func(http.Handler) http.Handler {
+	limiter := ratelimit.NewLimiter(limit)
+	policy := strconv.Itoa(limit.Requests) + ";w=" + strconv.Itoa(int(limit.Window.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result := limiter.Allow(key(r))
+
+			remaining, err := strconv.Atoi(w.Header().Get("RateLimit-Remaining"))
+			if err != nil || result.Remaining <= remaining {
+				w.Header().Set("RateLimit-Policy", policy)
+				w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+				w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+				w.Header().Set("RateLimit-Reset", seconds(result.Reset))
+			}
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", seconds(result.RetryAfter))
+				jsonutils.EncodeJson(w, r, http.StatusTooManyRequests, map[string]any{
+					"error": "too many requests, try again later",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// maxTokenRequestSize bounds the token request bodies read by
+// rateLimitByGrant, far above what a valid one takes.
+const maxTokenRequestSize = 64 << 10
+
+// rateLimitByGrant sends refresh token grants through the refresh limit and
+// every other token request through the password one, so clients sharing
+// an address keep refreshing their tokens while password guesses stay
+// tightly limited. The body is read here and handed on unchanged.
+func rateLimitByGrant(password, refresh func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		passwordNext, refreshNext := password(next), refresh(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxTokenRequestSize))
+			if err != nil {
+				jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+					"error": "failed to read the request body",
+				})
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			var grant struct {
+				GrantType string `json:"grant_type"`
+			}
+			if json.Unmarshal(body, &grant) == nil && grant.GrantType == user.RefreshTokenGrant {
+				refreshNext.ServeHTTP(w, r)
+				return
+			}
+			passwordNext.ServeHTTP(w, r)
+		})
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -1,8 +1,13 @@
 package api
 
 import (
+	"time"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/nathancamolez-dev/go-bid/internal/access"
+	"github.com/nathancamolez-dev/go-bid/internal/ratelimit"
 )
 
 func (api *Api) BindRoutes() {
@@ -13,32 +18,114 @@ func (api *Api) BindRoutes() {
 		api.Sessions.LoadAndSave,
 	)
 
-	// csrfMiddleware := csrf.Protect([]byte(os.Getenv("GOBID_CSRF_KEY")), csrf.Secure(false))
-
-	// api.Router.Use(csrfMiddleware)
+	// Groups sharing a limit share its buckets, on top of the overall limit
+	// of each IP address.
+	var (
+		overallLimit     = api.RateLimit(ratelimit.Limit{Requests: 300, Window: time.Minute}, api.rateLimitByIP)
+		credentialsLimit = api.RateLimit(ratelimit.Limit{Requests: 10, Window: time.Minute}, api.rateLimitByIP)
+		refreshLimit     = api.RateLimit(ratelimit.Limit{Requests: 120, Window: time.Minute}, api.rateLimitByIP)
+		userLimit        = api.RateLimit(ratelimit.Limit{Requests: 120, Window: time.Minute}, api.rateLimitByUser)
+		websocketLimit   = api.RateLimit(ratelimit.Limit{Requests: 30, Window: time.Minute}, api.rateLimitByUser)
+	)
 
 	api.Router.Route("/api", func(r chi.Router) {
 		r.Route("/v1", func(r chi.Router) {
-			// r.Get("/csrftoken", api.HandleGetCSRFtoken) is commented for as development purposes
-			r.Route("/users/", func(r chi.Router) {
-				r.Post("/signup", api.handleSignupUser)
-				r.Post("/login", api.handleLoginUser)
-				r.Group(func(r chi.Router) {
-					r.Use(api.AuthMiddleware)
-					r.Post("/logout", api.handleLogout)
-
-				})
+			r.Use(overallLimit)
 
+			// The token endpoints read credentials from the body and set no
+			// cookies, so clients without a csrf token can use them.
+			r.Route("/auth", func(r chi.Router) {
+				r.With(rateLimitByGrant(credentialsLimit, refreshLimit)).Post("/token", api.handleIssueToken)
+				r.With(refreshLimit).Post("/revoke", api.handleRevokeToken)
+				r.Get("/providers", api.handleListIdentityProviders)
+				r.Get("/providers/{provider}/login", api.handleStartProviderLogin)
+				r.Get("/providers/{provider}/callback", api.handleProviderCallback)
 			})
 
-			r.Route("/products", func(r chi.Router) {
-				r.Group(func(r chi.Router) {
-					r.Use(api.AuthMiddleware)
-					r.Post("/", api.handleCreateProduct)
+			r.Group(func(r chi.Router) {
+				r.Use(api.CSRFMiddleware)
+				r.Get("/csrftoken", api.HandleGetCSRFtoken)
+				r.Route("/users/", func(r chi.Router) {
+					r.Group(func(r chi.Router) {
+						r.Use(credentialsLimit)
+						r.Post("/signup", api.handleSignupUser)
+						r.Post("/login", api.handleLoginUser)
+						r.Post("/login/2fa", api.handleLoginTwoFactor)
+						r.Post("/verify", api.handleVerifyEmail)
+						r.Post("/verify/resend", api.handleResendVerification)
+						r.Post("/password/forgot", api.handleForgotPassword)
+						r.Post("/password/reset", api.handleResetPassword)
+						r.Post("/restore", api.handleRestoreAccount)
+					})
+					r.Get("/{user_id}", api.handleGetPublicProfile)
+					r.Get("/{user_id}/feedback", api.handleListFeedback)
+					r.Group(func(r chi.Router) {
+						r.Use(api.AuthMiddleware, userLimit)
+						r.With(api.RequireScope(access.ScopeProfileRead)).Get("/me", api.handleGetMe)
+						r.With(api.RequireScope(access.ScopeProfileWrite)).Patch("/me", api.handleUpdateMe)
+						r.With(api.RequireScope(access.ScopeProfileWrite)).
+							Put("/me/display_currency", api.handleSetDisplayCurrency)
+						r.With(api.RequireScope(access.ScopeWatchlistRead)).
+							Get("/me/watchlist", api.handleGetWatchlist)
+						r.With(api.RequireScope(access.ScopeProductsRead)).
+							Get("/me/listings", api.handleGetMyListings)
+						r.With(api.RequireScope(access.ScopeBidsRead)).
+							Get("/me/bids", api.handleGetMyBids)
+						r.With(api.RequireScope(access.ScopeBidsRead)).
+							Get("/me/credit", api.handleGetMyCredit)
+
+						r.Group(func(r chi.Router) {
+							r.Use(api.DenyApiTokens)
+							r.Post("/logout", api.handleLogout)
+							r.Post("/me/password", api.handleChangePassword)
+							r.Get("/me/sessions", api.handleListSessions)
+							r.Delete("/me/sessions/{session_id}", api.handleRevokeSession)
+							r.Post("/me/2fa/setup", api.handleSetupTwoFactor)
+							r.Post("/me/2fa/confirm", api.handleConfirmTwoFactor)
+							r.Post("/me/2fa/disable", api.handleDisableTwoFactor)
+							r.Get("/me/tokens", api.handleListApiTokens)
+							r.Post("/me/tokens", api.handleCreateApiToken)
+							r.Delete("/me/tokens/{token_id}", api.handleRevokeApiToken)
+							r.Get("/me/export", api.handleExportAccount)
+							r.Delete("/me", api.handleDeleteAccount)
+						})
 
-					r.Get("/ws/subscribe/{product_id}", api.handleSubscribeToAuction)
+					})
+
+				})
+
+				r.With(api.AuthMiddleware, websocketLimit, api.RequireScope(access.ScopeBidsWrite)).
+					Post("/ws/ticket", api.handleIssueWsTicket)
+
+				r.Route("/admin", func(r chi.Router) {
+					r.Use(api.AuthMiddleware, userLimit, api.DenyApiTokens, api.RequireRole(access.RoleModerator))
+					r.Get("/users", api.handleAdminListUsers)
+					r.Post("/users/{user_id}/suspend", api.handleAdminSuspendUser)
+					r.Post("/users/{user_id}/unsuspend", api.handleAdminUnsuspendUser)
+					r.With(api.RequireRole(access.RoleAdmin)).
+						Put("/users/{user_id}/role", api.handleAdminSetRole)
+					r.With(api.RequireRole(access.RoleAdmin)).
+						Put("/users/{user_id}/credit_limit", api.handleAdminSetCreditLimit)
+					r.Post("/products/{product_id}/close", api.handleAdminCloseAuction)
+					r.Get("/products/{product_id}/bids", api.handleAdminGetBidHistory)
 				})
 
+				r.Route("/products", func(r chi.Router) {
+					r.Group(func(r chi.Router) {
+						r.Use(api.AuthMiddleware, userLimit)
+						r.With(api.RequireScope(access.ScopeProductsWrite)).Post("/", api.handleCreateProduct)
+						r.With(api.RequireScope(access.ScopeWatchlistWrite)).
+							Post("/{product_id}/watch", api.handleWatchProduct)
+						r.With(api.RequireScope(access.ScopeWatchlistWrite)).
+							Delete("/{product_id}/watch", api.handleUnwatchProduct)
+						r.With(api.RequireScope(access.ScopeProfileWrite)).
+							Post("/{product_id}/feedback", api.handleLeaveFeedback)
+					})
+
+					r.With(api.WebsocketUpgrade, api.AuthMiddleware, websocketLimit, api.RequireScope(access.ScopeBidsWrite)).
+						Get("/ws/subscribe/{product_id}", api.handleSubscribeToAuction)
+
+				})
 			})
 		})
 
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
+	"github.com/nathancamolez-dev/go-bid/internal/usecase/user"
+)
+
+// handleIssueToken is the token endpoint of the stateless authentication
+// mode, used by clients that can't keep cookies such as the mobile app.
+func (api *Api) handleIssueToken(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.TokenReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	// Token responses must never be cached.
+	w.Header().Set("Cache-Control", "no-store")
+
+	var pair services.TokenPair
+	if data.GrantType == user.RefreshTokenGrant {
+		pair, err = api.AuthTokenService.RefreshTokens(r.Context(), data.RefreshToken)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+				jsonutils.EncodeJson(w, r, http.StatusUnauthorized, map[string]any{
+					"error": "invalid or expired refresh token",
+				})
+				return
+			}
+			if errors.Is(err, services.ErrAccountSuspended) {
+				writeSuspended(w, r, err)
+				return
+			}
+			if errors.Is(err, services.ErrAccountPendingDeletion) {
+				writePendingDeletion(w, r, err)
+				return
+			}
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "unexpected internal server error",
+			})
+			return
+		}
+
+		_ = jsonutils.EncodeJson(w, r, http.StatusOK, pair)
+		return
+	}
+
+	userId, ok := api.authenticatePasswordGrant(w, r, data)
+	if !ok {
+		return
+	}
+
+	pair, err = api.AuthTokenService.IssueTokens(r.Context(), userId)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, pair)
+}
+
+// authenticatePasswordGrant checks the credentials as handleLoginUser does,
+// except the two factor code has to come along with them.
+func (api *Api) authenticatePasswordGrant(
+	w http.ResponseWriter,
+	r *http.Request,
+	data user.TokenReq,
+) (uuid.UUID, bool) {
+	id, err := api.UserService.AuthenticateUser(r.Context(), data.Email, data.Password, api.loginIP(r))
+	if err != nil {
+		var locked services.AccountLockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(locked.RetryAfter.Seconds())+1))
+			jsonutils.EncodeJson(w, r, http.StatusTooManyRequests, map[string]any{
+				"error": "Too many failed login attempts, try again later",
+			})
+			return uuid.UUID{}, false
+		}
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			jsonutils.EncodeJson(w, r, http.StatusUnauthorized, map[string]any{
+				"error": "Invalid email or password",
+			})
+			return uuid.UUID{}, false
+		}
+		if errors.Is(err, services.ErrAccountSuspended) {
+			writeSuspended(w, r, err)
+			return uuid.UUID{}, false
+		}
+		if errors.Is(err, services.ErrAccountPendingDeletion) {
+			writePendingDeletion(w, r, err)
+			return uuid.UUID{}, false
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return uuid.UUID{}, false
+	}
+
+	twoFactor, err := api.UserService.TwoFactorEnabled(r.Context(), id)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return uuid.UUID{}, false
+	}
+	if !twoFactor {
+		return id, true
+	}
+
+	if data.Code == "" {
+		jsonutils.EncodeJson(w, r, http.StatusUnauthorized, map[string]any{
+			"error":               "two factor code required",
+			"two_factor_required": true,
+		})
+		return uuid.UUID{}, false
+	}
+	if err := api.UserService.VerifyTwoFactor(r.Context(), id, data.Code); err != nil {
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			jsonutils.EncodeJson(w, r, http.StatusUnauthorized, map[string]any{
+				"error": "invalid two factor code",
+			})
+			return uuid.UUID{}, false
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return uuid.UUID{}, false
+	}
+	return id, true
+}
+
+func (api *Api) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.RevokeTokenReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	if err := api.AuthTokenService.RevokeRefreshToken(r.Context(), data.RefreshToken); err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "refresh token revoked",
+	})
+}
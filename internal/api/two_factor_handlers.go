@@ -0,0 +1,200 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
+	"github.com/nathancamolez-dev/go-bid/internal/usecase/user"
+)
+
+const (
+	// twoFactorLoginWindow is how long a password login waits for its code.
+	twoFactorLoginWindow   = 5 * time.Minute
+	maxTwoFactorLoginTries = 5
+)
+
+// startTwoFactorLogin stores a partial session, which AuthMiddleware does not
+// accept, until handleLoginTwoFactor checks the code.
+func (api *Api) startTwoFactorLogin(r *http.Request, userId uuid.UUID) error {
+	if err := api.Sessions.RenewToken(r.Context()); err != nil {
+		return err
+	}
+
+	api.Sessions.Put(r.Context(), "PendingTwoFactorUserId", userId)
+	api.Sessions.Put(r.Context(), "PendingTwoFactorAt", time.Now().Unix())
+	api.Sessions.Put(r.Context(), "PendingTwoFactorTries", 0)
+	return nil
+}
+
+func (api *Api) clearTwoFactorLogin(r *http.Request) {
+	api.Sessions.Remove(r.Context(), "PendingTwoFactorUserId")
+	api.Sessions.Remove(r.Context(), "PendingTwoFactorAt")
+	api.Sessions.Remove(r.Context(), "PendingTwoFactorTries")
+}
+
+func (api *Api) handleLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.TwoFactorCodeReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	userId, ok := api.Sessions.Get(r.Context(), "PendingTwoFactorUserId").(uuid.UUID)
+	startedAt := time.Unix(api.Sessions.GetInt64(r.Context(), "PendingTwoFactorAt"), 0)
+	tries := api.Sessions.GetInt(r.Context(), "PendingTwoFactorTries")
+	if !ok || time.Since(startedAt) > twoFactorLoginWindow || tries >= maxTwoFactorLoginTries {
+		api.clearTwoFactorLogin(r)
+		jsonutils.EncodeJson(w, r, http.StatusUnauthorized, map[string]any{
+			"error": "log in with your email and password again",
+		})
+		return
+	}
+
+	if err := api.UserService.VerifyTwoFactor(r.Context(), userId, data.Code); err != nil {
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			api.Sessions.Put(r.Context(), "PendingTwoFactorTries", tries+1)
+			jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+				"error": "invalid two factor code",
+			})
+			return
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	api.clearTwoFactorLogin(r)
+	if err := api.logIn(r, userId); err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "!!successfully logged in",
+	})
+}
+
+func (api *Api) handleSetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	setup, err := api.UserService.SetupTwoFactor(r.Context(), userId)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			jsonutils.EncodeJson(w, r, http.StatusConflict, map[string]any{
+				"error": "two factor authentication is already enabled",
+			})
+			return
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, setup)
+}
+
+func (api *Api) handleConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.TwoFactorCodeReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	codes, err := api.UserService.ConfirmTwoFactor(r.Context(), userId, data.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+				"error": "invalid two factor code",
+			})
+		case errors.Is(err, services.ErrTwoFactorNotPending):
+			jsonutils.EncodeJson(w, r, http.StatusConflict, map[string]any{
+				"error": "start the two factor setup first",
+			})
+		case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+			jsonutils.EncodeJson(w, r, http.StatusConflict, map[string]any{
+				"error": "two factor authentication is already enabled",
+			})
+		default:
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "unexpected internal server error",
+			})
+		}
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message":        "two factor authentication enabled, store the recovery codes safely",
+		"recovery_codes": codes,
+	})
+}
+
+func (api *Api) handleDisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.DisableTwoFactorReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.UserService.DisableTwoFactor(r.Context(), userId, data.Password); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+				"error": "password is incorrect",
+			})
+		case errors.Is(err, services.ErrTwoFactorNotEnabled):
+			jsonutils.EncodeJson(w, r, http.StatusConflict, map[string]any{
+				"error": "two factor authentication is not enabled",
+			})
+		default:
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "unexpected internal server error",
+			})
+		}
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "two factor authentication disabled",
+	})
+}
@@ -2,7 +2,13 @@ package api
 
 import (
 	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 
 	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
 	"github.com/nathancamolez-dev/go-bid/internal/services"
@@ -34,10 +40,15 @@ func (api *Api) handleSignupUser(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
 	}
 
 	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
 		"user_id": id,
+		"message": "check your email to verify your account",
 	})
 }
 
@@ -57,20 +68,37 @@ func (api *Api) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := api.UserService.AuthenticateUser(r.Context(), data.Email, data.Password)
+	id, err := api.UserService.AuthenticateUser(r.Context(), data.Email, data.Password, api.loginIP(r))
 	if err != nil {
+		var locked services.AccountLockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(locked.RetryAfter.Seconds())+1))
+			_ = jsonutils.EncodeJson(w, r, http.StatusTooManyRequests, map[string]any{
+				"error": "Too many failed login attempts, try again later",
+			})
+			return
+		}
 		if errors.Is(err, services.ErrInvalidCredentials) {
 			_ = jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
 				"error": "Invalid email or password",
 			})
 			return
 		}
+		if errors.Is(err, services.ErrAccountSuspended) {
+			writeSuspended(w, r, err)
+			return
+		}
+		if errors.Is(err, services.ErrAccountPendingDeletion) {
+			writePendingDeletion(w, r, err)
+			return
+		}
 		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
 			"error": "unexpected internal server error",
 		})
 		return
 	}
-	err = api.Sessions.RenewToken(r.Context())
+
+	twoFactor, err := api.UserService.TwoFactorEnabled(r.Context(), id)
 	if err != nil {
 		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
 			"error": "unexpected internal server error",
@@ -78,7 +106,29 @@ func (api *Api) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	api.Sessions.Put(r.Context(), "AuthenticateUserId", id)
+	if twoFactor {
+		// Only a partial session until the TOTP code is checked by
+		// handleLoginTwoFactor.
+		if err := api.startTwoFactorLogin(r, id); err != nil {
+			jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "unexpected internal server error",
+			})
+			return
+		}
+
+		_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+			"message":             "enter your two factor code",
+			"two_factor_required": true,
+		})
+		return
+	}
+
+	if err := api.logIn(r, id); err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
 
 	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
 		"message": "!!successfully logged in",
@@ -86,7 +136,23 @@ func (api *Api) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// logIn turns the request's session into an authenticated session for the
+// user.
+func (api *Api) logIn(r *http.Request, userId uuid.UUID) error {
+	if err := api.Sessions.RenewToken(r.Context()); err != nil {
+		return err
+	}
+
+	api.Sessions.Put(r.Context(), "AuthenticateUserId", userId)
+	api.Sessions.Put(r.Context(), "AuthenticatedAt", time.Now().Unix())
+
+	return api.SessionService.IndexSession(r.Context(),
+		api.Sessions.Token(r.Context()), userId, r.UserAgent(), api.clientIP(r))
+}
+
 func (api *Api) handleLogout(w http.ResponseWriter, r *http.Request) {
+	token := api.Sessions.Token(r.Context())
+
 	err := api.Sessions.RenewToken(r.Context())
 	if err != nil {
 		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
@@ -97,8 +163,279 @@ func (api *Api) handleLogout(w http.ResponseWriter, r *http.Request) {
 
 	api.Sessions.Remove(r.Context(), "AuthenticateUserId")
 
+	if err := api.SessionService.ForgetSession(r.Context(), token); err != nil {
+		slog.Error("Failed to forget session", "error", err)
+	}
+
 	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
 		"message": "successfully logged out",
 	})
 
 }
+
+func (api *Api) handleSetDisplayCurrency(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.SetDisplayCurrencyReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	userID, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.UserService.SetDisplayCurrency(r.Context(), userID, data.Currency); err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"display_currency": data.Currency,
+	})
+}
+
+func (api *Api) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	me, err := api.UserService.GetProfile(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			jsonutils.EncodeJson(w, r, http.StatusNotFound, map[string]any{
+				"error": "user not found",
+			})
+			return
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, me)
+}
+
+func (api *Api) handleUpdateMe(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.UpdateProfileReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	userID, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	me, err := api.UserService.UpdateProfile(r.Context(), userID, data.UserName, data.Bio)
+	if err != nil {
+		if errors.Is(err, services.ErrDuplicatedEmailOrUsername) {
+			_ = jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+				"error": "Already exists a user with this username",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			jsonutils.EncodeJson(w, r, http.StatusNotFound, map[string]any{
+				"error": "user not found",
+			})
+			return
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, services.NewProfile(me))
+}
+
+func (api *Api) handleGetPublicProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid uuid",
+		})
+		return
+	}
+
+	profile, err := api.UserService.GetPublicProfile(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			jsonutils.EncodeJson(w, r, http.StatusNotFound, map[string]any{
+				"error": "user not found",
+			})
+			return
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, profile)
+}
+
+func (api *Api) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.VerifyEmailReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	if _, err := api.UserService.VerifyEmail(r.Context(), data.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidToken) {
+			jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+				"error": "invalid or expired token",
+			})
+			return
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "email verified",
+	})
+}
+
+// emailLookupResponseTime pads every response of the routes taking an email
+// to the same duration, so they don't tell whether it belongs to an account.
+const emailLookupResponseTime = 500 * time.Millisecond
+
+func (api *Api) handleResendVerification(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	data, problems, err := jsonutils.DecodeValidJson[user.ResendVerificationReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	if err := api.UserService.ResendVerification(r.Context(), data.Email); err != nil {
+		slog.Error("Failed to resend verification email", "error", err)
+	}
+
+	time.Sleep(time.Until(start.Add(emailLookupResponseTime)))
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "if an unverified account exists for this email, a new token has been sent to it",
+	})
+}
+
+func (api *Api) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	data, problems, err := jsonutils.DecodeValidJson[user.ForgotPasswordReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	if err := api.UserService.ForgotPassword(r.Context(), data.Email); err != nil {
+		slog.Error("Failed to start password reset", "error", err)
+	}
+
+	time.Sleep(time.Until(start.Add(emailLookupResponseTime)))
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "if an account exists for this email, a reset token has been sent to it",
+	})
+}
+
+func (api *Api) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.ResetPasswordReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	if err := api.UserService.ResetPassword(r.Context(), data.Token, data.Password); err != nil {
+		if errors.Is(err, services.ErrInvalidToken) {
+			jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+				"error": "invalid or expired token",
+			})
+			return
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "password updated, log in again with the new password",
+	})
+}
+
+func (api *Api) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := jsonutils.DecodeValidJson[user.ChangePasswordReq](r)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusUnprocessableEntity, map[string]any{
+			"error":    err.Error(),
+			"problems": problems,
+		})
+		return
+	}
+
+	userID, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	err = api.UserService.ChangePassword(r.Context(),
+		userID, data.CurrentPassword, data.NewPassword, api.Sessions.Token(r.Context()))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			jsonutils.EncodeJson(w, r, http.StatusBadRequest, map[string]any{
+				"error": "current password is incorrect",
+			})
+			return
+		}
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJson(w, r, http.StatusOK, map[string]any{
+		"message": "password changed, other sessions were logged out",
+	})
+}
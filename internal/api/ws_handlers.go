@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jsonutils"
+	"github.com/nathancamolez-dev/go-bid/internal/services"
+)
+
+// handleIssueWsTicket returns a single use ticket for clients that can't
+// authenticate the websocket connection with the cookie or a header. It is
+// passed as ?ticket= when connecting.
+func (api *Api) handleIssueWsTicket(w http.ResponseWriter, r *http.Request) {
+	userId, ok := authenticatedUserId(r)
+	if !ok {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	ticket, err := api.WsTicketService.IssueWsTicket(r.Context(), userId)
+	if err != nil {
+		jsonutils.EncodeJson(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	_ = jsonutils.EncodeJson(w, r, http.StatusCreated, map[string]any{
+		"ticket":     ticket,
+		"expires_in": int(services.WsTicketTTL.Seconds()),
+	})
+}
+
+// WebsocketUpgrade refuses requests that aren't websocket upgrades, or that
+// come from an origin that isn't allowed, before anything else runs. Doing
+// it ahead of the upgrade lets clients get a proper HTTP error.
+func (api *Api) WebsocketUpgrade(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !websocket.IsWebSocketUpgrade(r) {
+			w.Header().Set("Upgrade", "websocket")
+			jsonutils.EncodeJson(w, r, http.StatusUpgradeRequired, map[string]any{
+				"message": "this route only accepts websocket connections",
+			})
+			return
+		}
+		if !api.CheckOrigin(r) {
+			jsonutils.EncodeJson(w, r, http.StatusForbidden, map[string]any{
+				"message": "origin not allowed",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CheckOrigin accepts websocket connections from the API's own host and from
+// WsAllowedOrigins. Browsers always send Origin, so requests without it come
+// from other clients, which can't ride on the user's cookies.
+func (api *Api) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	origin = strings.ToLower(u.Scheme + "://" + u.Host)
+	return slices.ContainsFunc(api.WsAllowedOrigins, func(allowed string) bool {
+		return strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin)
+	})
+}
+
+// wsTicketAuthenticator accepts the tickets of handleIssueWsTicket, only
+// on websocket connections.
+type wsTicketAuthenticator struct {
+	service services.WsTicketService
+}
+
+func (a wsTicketAuthenticator) authenticate(r *http.Request) (authInfo, error) {
+	ticket := r.URL.Query().Get("ticket")
+	if ticket == "" || !websocket.IsWebSocketUpgrade(r) {
+		return authInfo{}, errNoCredentials
+	}
+
+	userId, err := a.service.ConsumeWsTicket(r.Context(), ticket)
+	if err != nil {
+		return authInfo{}, err
+	}
+	return authInfo{UserId: userId}, nil
+}
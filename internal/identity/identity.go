@@ -0,0 +1,134 @@
+// Package identity lets users log in with external identity providers,
+// through the OAuth 2.0 authorization code flow with PKCE.
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var ErrInvalidIdentity = errors.New("invalid identity")
+
+// Identity is who the provider says the user is. Subject is stable for a
+// user of a provider, unlike the email.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is an external identity provider. The verifier is the PKCE code
+// verifier and the nonce ties the ID token to the login that asked for it,
+// both generated for every login.
+type Provider interface {
+	Name() string
+	AuthCodeURL(state, verifier, nonce string) string
+	Exchange(ctx context.Context, code, verifier, nonce string) (Identity, error)
+}
+
+// Login holds the secrets of a login in progress, kept by the client's
+// session until the provider redirects back.
+type Login struct {
+	State    string
+	Verifier string
+	Nonce    string
+}
+
+func NewLogin() Login {
+	return Login{
+		State:    oauth2.GenerateVerifier(),
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    oauth2.GenerateVerifier(),
+	}
+}
+
+// OIDCProvider is any OpenID Connect provider, configured through its
+// discovery document.
+type OIDCProvider struct {
+	name     string
+	config   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+type OIDCConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// NewOIDCProvider fetches the discovery document of the issuer.
+func NewOIDCProvider(ctx context.Context, cfg OIDCConfig) (*OIDCProvider, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("identity: discovering %s: %w", cfg.Name, err)
+	}
+
+	return &OIDCProvider{
+		name: cfg.Name,
+		config: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}, nil
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+func (p *OIDCProvider) AuthCodeURL(state, verifier, nonce string) string {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce))
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string) (Identity, error) {
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %w", ErrInvalidIdentity, err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Identity{}, fmt.Errorf("%w: no id_token in the token response", ErrInvalidIdentity)
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %w", ErrInvalidIdentity, err)
+	}
+	if idToken.Nonce != nonce {
+		return Identity{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIdentity)
+	}
+
+	var claims struct {
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, fmt.Errorf("%w: %w", ErrInvalidIdentity, err)
+	}
+
+	name := claims.PreferredUsername
+	if name == "" {
+		name = claims.Name
+	}
+	return Identity{
+		Provider:      p.name,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          name,
+	}, nil
+}
@@ -0,0 +1,36 @@
+package money
+
+import "errors"
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+// DefaultCurrency is the currency of products created without one, the
+// same as the default of the products.currency column.
+const DefaultCurrency Currency = "USD"
+
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// exponents holds the number of minor unit digits of each supported currency.
+var exponents = map[Currency]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2,
+	"CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2,
+	"HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "ISK": 0, "JOD": 3,
+	"JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "NOK": 2, "NZD": 2, "OMR": 3,
+	"PEN": 2, "PHP": 2, "PLN": 2, "PYG": 0, "RON": 2, "SAR": 2, "SEK": 2,
+	"SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2, "UAH": 2, "USD": 2,
+	"UYU": 2, "VND": 0, "ZAR": 2,
+}
+
+func (c Currency) Valid() bool {
+	_, ok := exponents[c]
+	return ok
+}
+
+// Exponent is the number of decimal places of c, two for unknown codes.
+func (c Currency) Exponent() int {
+	if exp, ok := exponents[c]; ok {
+		return exp
+	}
+	return 2
+}
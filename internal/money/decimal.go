@@ -0,0 +1,101 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal amount such as "12.34", as written by clients.
+// It only becomes Money once the currency it is expressed in is known.
+type Decimal string
+
+// ParseDecimal checks that s is a plain decimal number.
+func ParseDecimal(s string) (Decimal, error) {
+	d := Decimal(strings.TrimSpace(s))
+	if _, _, _, err := d.parts(); err != nil {
+		return "", err
+	}
+	return d, nil
+}
+
+// Scale is the number of fractional digits written.
+func (d Decimal) Scale() int {
+	_, frac, _, _ := d.parts()
+	return len(frac)
+}
+
+// Money converts d into minor units of c, refusing amounts written with more
+// decimal places than c has.
+func (d Decimal) Money(c Currency) (Money, error) {
+	whole, frac, negative, err := d.parts()
+	if err != nil {
+		return 0, err
+	}
+
+	exp := c.Exponent()
+	if len(frac) > exp {
+		frac = strings.TrimRight(frac, "0")
+		if len(frac) > exp {
+			return 0, fmt.Errorf("%w: %s allows %d", ErrTooPrecise, c, exp)
+		}
+	}
+
+	scale := pow10(exp)
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/scale-1 {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidAmount, string(d))
+	}
+
+	var minor int64
+	if exp > 0 {
+		minor, _ = strconv.ParseInt(frac+strings.Repeat("0", exp-len(frac)), 10, 64)
+	}
+
+	m := Money(units*scale + minor)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(d))
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: amounts must be decimal strings", ErrInvalidAmount)
+	}
+
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) parts() (whole, frac string, negative bool, err error) {
+	s := string(d)
+	negative = strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, _ = strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(frac) {
+		return "", "", false, fmt.Errorf("%w: %q", ErrInvalidAmount, string(d))
+	}
+	return whole, frac, negative, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Money is an amount in the minor units of its currency (cents for USD, yen
+// for JPY). It is stored as a BIGINT, so amounts are never rounded through a
+// float; it travels through JSON as a Decimal alongside its currency.
+type Money int64
+
+var (
+	ErrInvalidAmount = errors.New("invalid amount")
+	ErrTooPrecise    = errors.New("amount has more decimal places than its currency allows")
+)
+
+// Decimal writes m in the major units of c.
+func (m Money) Decimal(c Currency) Decimal {
+	exp := c.Exponent()
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+
+	if exp == 0 {
+		return Decimal(fmt.Sprintf("%s%d", sign, v))
+	}
+	scale := pow10(exp)
+	return Decimal(fmt.Sprintf("%s%d.%0*d", sign, v/scale, exp, v%scale))
+}
+
+// Int64Value and ScanInt64 make pgx store Money as its minor units.
+func (m Money) Int64Value() (pgtype.Int8, error) {
+	return pgtype.Int8{Int64: int64(m), Valid: true}, nil
+}
+
+func (m *Money) ScanInt64(v pgtype.Int8) error {
+	if !v.Valid {
+		return fmt.Errorf("%w: cannot scan NULL into Money", ErrInvalidAmount)
+	}
+	*m = Money(v.Int64)
+	return nil
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for range n {
+		p *= 10
+	}
+	return p
+}
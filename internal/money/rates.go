@@ -0,0 +1,83 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// Rates converts between currencies for display. Each rate is the price of
+// one unit of the base currency, so the base itself is always 1.
+type Rates struct {
+	base  Currency
+	rates map[Currency]*big.Rat
+}
+
+type ratesFile struct {
+	Base  Currency             `json:"base"`
+	Rates map[Currency]Decimal `json:"rates"`
+}
+
+// LoadRates reads a rate table such as
+//
+//	{"base": "USD", "rates": {"EUR": "0.92", "BRL": "5.43"}}
+func LoadRates(path string) (Rates, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Rates{}, err
+	}
+
+	var file ratesFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return Rates{}, fmt.Errorf("failed to decode rates %w", err)
+	}
+	if !file.Base.Valid() {
+		return Rates{}, fmt.Errorf("%w: base %q", ErrUnknownCurrency, file.Base)
+	}
+
+	rates := Rates{base: file.Base, rates: map[Currency]*big.Rat{file.Base: big.NewRat(1, 1)}}
+	for c, d := range file.Rates {
+		if !c.Valid() {
+			return Rates{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, c)
+		}
+		rate, ok := new(big.Rat).SetString(string(d))
+		if !ok || rate.Sign() <= 0 {
+			return Rates{}, fmt.Errorf("%w: rate for %s", ErrInvalidAmount, c)
+		}
+		rates.rates[c] = rate
+	}
+	return rates, nil
+}
+
+// Convert expresses m, in minor units of from, in minor units of to, rounding
+// half away from zero. It reports false when either rate is unknown.
+func (r Rates) Convert(m Money, from, to Currency) (Money, bool) {
+	if from == to {
+		return m, true
+	}
+	fromRate, ok := r.rates[from]
+	if !ok {
+		return 0, false
+	}
+	toRate, ok := r.rates[to]
+	if !ok {
+		return 0, false
+	}
+
+	v := new(big.Rat).SetFrac64(int64(m), pow10(from.Exponent()))
+	v.Quo(v, fromRate)
+	v.Mul(v, toRate)
+	v.Mul(v, new(big.Rat).SetInt64(pow10(to.Exponent())))
+
+	// Round half away from zero.
+	num, den := v.Num(), v.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+	if !q.IsInt64() {
+		return 0, false
+	}
+	return Money(q.Int64()), true
+}
@@ -0,0 +1,121 @@
+// Package ratelimit implements token buckets, alone or keyed by client.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit allows Requests per Window, refilled continuously, so a client that
+// waited a whole window can send Requests at once.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Window.Seconds()
+}
+
+// Result is the state of a bucket after a request.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed, zero when
+	// this one was.
+	RetryAfter time.Duration
+}
+
+// Bucket is a single token bucket. It is not safe for concurrent use.
+type Bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+func NewBucket(limit Limit) *Bucket {
+	return &Bucket{limit: limit, tokens: float64(limit.Requests)}
+}
+
+// Take spends a token if there is one left.
+func (b *Bucket) Take(now time.Time) Result {
+	b.refill(now)
+
+	result := Result{Limit: b.limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = b.timeFor(1 - b.tokens)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = b.timeFor(float64(b.limit.Requests) - b.tokens)
+	return result
+}
+
+func (b *Bucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		elapsed := now.Sub(b.last).Seconds()
+		b.tokens = min(float64(b.limit.Requests), b.tokens+elapsed*b.limit.rate())
+	}
+	b.last = now
+}
+
+func (b *Bucket) full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= float64(b.limit.Requests)
+}
+
+func (b *Bucket) timeFor(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / b.limit.rate() * float64(time.Second)))
+}
+
+// sweepInterval is how often a Limiter forgets the clients whose bucket
+// is full again, which behave as new ones.
+const sweepInterval = time.Minute
+
+// Limiter keeps a bucket per key, such as a user or an IP address. Buckets
+// live in memory, so each instance counts its own requests.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     Limit
+	buckets   map[string]*Bucket
+	lastSweep time.Time
+}
+
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		buckets: make(map[string]*Bucket),
+	}
+}
+
+// Allow takes a token from the bucket of key.
+func (l *Limiter) Allow(key string) Result {
+	return l.allow(key, time.Now())
+}
+
+func (l *Limiter) allow(key string, now time.Time) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		for k, b := range l.buckets {
+			if b.full(now) {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = NewBucket(l.limit)
+		l.buckets[key] = bucket
+	}
+	return bucket.Take(now)
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketTake(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := Limit{Requests: 3, Window: 3 * time.Second}
+
+	type take struct {
+		at   time.Duration
+		want Result
+	}
+
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			name: "spends the burst then refuses",
+			takes: []take{
+				{0, Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+				{0, Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 2 * time.Second}},
+				{0, Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second}},
+				{0, Result{Limit: 3, Remaining: 0, Reset: 3 * time.Second, RetryAfter: time.Second}},
+			},
+		},
+		{
+			name: "refills continuously",
+			takes: []take{
+				{0, Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+				{0, Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 2 * time.Second}},
+				{0, Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second}},
+				{500 * time.Millisecond, Result{
+					Limit: 3, Remaining: 0, Reset: 2500 * time.Millisecond, RetryAfter: 500 * time.Millisecond,
+				}},
+				{time.Second, Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second}},
+				{2500 * time.Millisecond, Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 2500 * time.Millisecond}},
+			},
+		},
+		{
+			name: "never holds more than the limit",
+			takes: []take{
+				{0, Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+				{time.Hour, Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := NewBucket(limit)
+			for i, take := range tt.takes {
+				got := bucket.Take(start.Add(take.at))
+				if got != take.want {
+					t.Errorf("take %d at %s = %+v, want %+v", i, take.at, got, take.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterKeepsABucketPerKey(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(Limit{Requests: 1, Window: time.Minute})
+
+	if !limiter.allow("a", now).Allowed {
+		t.Fatal("first request of a was refused")
+	}
+	if limiter.allow("a", now).Allowed {
+		t.Fatal("second request of a was allowed")
+	}
+	if !limiter.allow("b", now).Allowed {
+		t.Fatal("first request of b was refused")
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// The buckets are used at start, and the sweep happens on a
+		// request for another key after the delay.
+		delay time.Duration
+		want  []string
+	}{
+		{name: "keeps buckets before the interval", delay: sweepInterval / 2, want: []string{"full", "spent", "other"}},
+		{name: "drops full buckets after the interval", delay: sweepInterval + time.Second, want: []string{"spent", "other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A token comes back every sweep interval, so "full" is full
+			// again after one and "spent" takes ten.
+			limiter := NewLimiter(Limit{Requests: 10, Window: 10 * sweepInterval})
+			limiter.allow("full", start)
+			for range 10 {
+				limiter.allow("spent", start)
+			}
+
+			limiter.allow("other", start.Add(tt.delay))
+
+			if len(limiter.buckets) != len(tt.want) {
+				t.Fatalf("got %d buckets, want %v", len(limiter.buckets), tt.want)
+			}
+			for _, key := range tt.want {
+				if _, ok := limiter.buckets[key]; !ok {
+					t.Errorf("bucket %q was dropped", key)
+				}
+			}
+		})
+	}
+}
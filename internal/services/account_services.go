@@ -0,0 +1,536 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/nathancamolez-dev/go-bid/internal/identity"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+var (
+	ErrAccountPendingDeletion   = errors.New("account scheduled for deletion")
+	ErrActiveAuctions           = errors.New("the user has active auctions")
+	ErrReauthenticationRequired = errors.New("a recent login is required")
+)
+
+const (
+	// deletionGracePeriod is how long a deleted account can be restored.
+	deletionGracePeriod = 30 * 24 * time.Hour
+
+	purgeInterval  = time.Hour
+	purgeBatchSize = 50
+
+	// reauthenticationWindow is how recent a login must be to stand in for
+	// the password of accounts linked to an identity provider.
+	reauthenticationWindow = 10 * time.Minute
+)
+
+// PendingDeletionError is returned, matching ErrAccountPendingDeletion, for
+// accounts that are going to be deleted.
+type PendingDeletionError struct {
+	ScheduledFor time.Time
+}
+
+func (e PendingDeletionError) Error() string {
+	return fmt.Sprintf("%s on %s", ErrAccountPendingDeletion, e.ScheduledFor.Format(time.DateOnly))
+}
+
+func (e PendingDeletionError) Is(target error) bool {
+	return target == ErrAccountPendingDeletion
+}
+
+// pendingDeletion returns the PendingDeletionError of accounts scheduled
+// for deletion.
+func pendingDeletion(user pgstore.User) error {
+	if !user.DeletionScheduledFor.Valid {
+		return nil
+	}
+	return PendingDeletionError{ScheduledFor: user.DeletionScheduledFor.Time}
+}
+
+// AccountExport is every piece of personal data kept about a user.
+type AccountExport struct {
+	ExportedAt   time.Time          `json:"exported_at"`
+	Profile      Profile            `json:"profile"`
+	Products     []ExportedProduct  `json:"products"`
+	Bids         []ExportedBid      `json:"bids"`
+	Sessions     []UserSession      `json:"sessions"`
+	ApiTokens    []ApiToken         `json:"api_tokens"`
+	Identities   []ExportedIdentity `json:"identities"`
+	LoginHistory []ExportedLogin    `json:"login_history"`
+
+	Watchlist     []ExportedWatch        `json:"watchlist"`
+	Notifications []ExportedNotification `json:"notifications"`
+	FeedbackGiven []ExportedFeedback     `json:"feedback_given"`
+	CreditLimits  []Credit               `json:"credit_limits"`
+}
+
+type ExportedProduct struct {
+	ID          uuid.UUID      `json:"id"`
+	ProductName string         `json:"product_name"`
+	Description string         `json:"description"`
+	Baseprice   money.Decimal  `json:"baseprice"`
+	Currency    money.Currency `json:"currency"`
+	AuctionEnd  time.Time      `json:"auction_end"`
+	IsSold      bool           `json:"is_sold"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type ExportedBid struct {
+	ID          uuid.UUID      `json:"id"`
+	ProductID   uuid.UUID      `json:"product_id"`
+	ProductName string         `json:"product_name"`
+	Amount      money.Decimal  `json:"amount"`
+	Currency    money.Currency `json:"currency"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type ExportedIdentity struct {
+	Provider    string    `json:"provider"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+type ExportedLogin struct {
+	IPAddress string    `json:"ip_address"`
+	Outcome   string    `json:"outcome"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportedWatch struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	WatchedAt   time.Time `json:"watched_at"`
+}
+
+type ExportedNotification struct {
+	ID          uuid.UUID       `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	DeliveredAt *time.Time      `json:"delivered_at"`
+}
+
+type ExportedFeedback struct {
+	ID          uuid.UUID `json:"id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Role        string    `json:"role"`
+	ToUserID    uuid.UUID `json:"to_user_id"`
+	Rating      int16     `json:"rating"`
+	Comment     string    `json:"comment"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ExportAccount gathers the data of the user, sessions being flagged as
+// current against currentToken.
+func (us *UserServices) ExportAccount(ctx context.Context, id uuid.UUID, currentToken string) (AccountExport, error) {
+	user, err := us.GetUserById(ctx, id)
+	if err != nil {
+		return AccountExport{}, err
+	}
+
+	export := AccountExport{
+		ExportedAt: time.Now(),
+		Profile:    NewProfile(user),
+	}
+
+	products, err := us.queries.ListProductsBySeller(ctx, id)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	export.Products = make([]ExportedProduct, 0, len(products))
+	for _, p := range products {
+		export.Products = append(export.Products, ExportedProduct{
+			ID:          p.ID,
+			ProductName: p.ProductName,
+			Description: p.Description,
+			Baseprice:   p.Baseprice.Decimal(p.Currency),
+			Currency:    p.Currency,
+			AuctionEnd:  p.AuctionEnd,
+			IsSold:      p.IsSold,
+			CreatedAt:   p.CreatedAt,
+		})
+	}
+
+	bids, err := us.queries.ListBidsByUser(ctx, id)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	export.Bids = make([]ExportedBid, 0, len(bids))
+	for _, b := range bids {
+		export.Bids = append(export.Bids, ExportedBid{
+			ID:          b.ID,
+			ProductID:   b.ProductID,
+			ProductName: b.ProductName,
+			Amount:      b.BidAmount.Decimal(b.Currency),
+			Currency:    b.Currency,
+			CreatedAt:   b.CreatedAt,
+		})
+	}
+
+	sessions, err := us.queries.ListUserSessions(ctx, id)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	export.Sessions = make([]UserSession, 0, len(sessions))
+	for _, s := range sessions {
+		export.Sessions = append(export.Sessions, UserSession{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IPAddress:  s.IpAddress,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    s.Token == currentToken,
+		})
+	}
+
+	tokens, err := us.queries.ListApiTokens(ctx, id)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	export.ApiTokens = make([]ApiToken, 0, len(tokens))
+	for _, t := range tokens {
+		export.ApiTokens = append(export.ApiTokens, newApiToken(t))
+	}
+
+	identities, err := us.queries.ListUserIdentities(ctx, id)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	export.Identities = make([]ExportedIdentity, 0, len(identities))
+	for _, i := range identities {
+		export.Identities = append(export.Identities, ExportedIdentity{
+			Provider:    i.Provider,
+			Subject:     i.Subject,
+			Email:       i.Email,
+			CreatedAt:   i.CreatedAt,
+			LastLoginAt: i.LastLoginAt,
+		})
+	}
+
+	logins, err := us.queries.ListLoginAttemptsByUser(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return AccountExport{}, err
+	}
+	export.LoginHistory = make([]ExportedLogin, 0, len(logins))
+	for _, l := range logins {
+		export.LoginHistory = append(export.LoginHistory, ExportedLogin{
+			IPAddress: l.IpAddress,
+			Outcome:   l.Outcome,
+			CreatedAt: l.CreatedAt,
+		})
+	}
+
+	watchlist, err := us.queries.ListWatchlistByUser(ctx, id)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	export.Watchlist = make([]ExportedWatch, 0, len(watchlist))
+	for _, w := range watchlist {
+		export.Watchlist = append(export.Watchlist, ExportedWatch{
+			ProductID:   w.ProductID,
+			ProductName: w.ProductName,
+			WatchedAt:   w.CreatedAt,
+		})
+	}
+
+	notifications, err := us.queries.ListNotificationsByUser(ctx, id)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	export.Notifications = make([]ExportedNotification, 0, len(notifications))
+	for _, n := range notifications {
+		notification := ExportedNotification{
+			ID:        n.ID,
+			Kind:      n.Kind,
+			Payload:   n.Payload,
+			Status:    n.Status,
+			CreatedAt: n.CreatedAt,
+		}
+		if n.DeliveredAt.Valid {
+			notification.DeliveredAt = &n.DeliveredAt.Time
+		}
+		export.Notifications = append(export.Notifications, notification)
+	}
+
+	feedback, err := us.queries.ListFeedbackByUser(ctx, id)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	export.FeedbackGiven = make([]ExportedFeedback, 0, len(feedback))
+	for _, f := range feedback {
+		export.FeedbackGiven = append(export.FeedbackGiven, ExportedFeedback{
+			ID:          f.ID,
+			ProductID:   f.ProductID,
+			ProductName: f.ProductName,
+			Role:        f.Role,
+			ToUserID:    f.ToUserID,
+			Rating:      f.Rating,
+			Comment:     f.Comment,
+			CreatedAt:   f.CreatedAt,
+		})
+	}
+
+	export.CreditLimits, err = us.GetCredit(ctx, id)
+	if err != nil {
+		return AccountExport{}, err
+	}
+
+	return export, nil
+}
+
+// DeleteAccount schedules the account for deletion after the grace period
+// and logs the user out everywhere. Users who sell or lead an auction that
+// is still running have to wait for it to end. The deletion is confirmed
+// with the password or, for accounts linked to an identity provider, by a
+// login at loggedInAt within the reauthentication window.
+func (us *UserServices) DeleteAccount(
+	ctx context.Context,
+	id uuid.UUID,
+	password string,
+	loggedInAt time.Time,
+) (time.Time, error) {
+	user, err := us.GetUserById(ctx, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := us.confirmUser(ctx, user, password, loggedInAt); err != nil {
+		return time.Time{}, err
+	}
+
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	active, err := queries.CountActiveAuctionsOfUser(ctx, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if active > 0 {
+		return time.Time{}, ErrActiveAuctions
+	}
+
+	scheduledFor := time.Now().Add(deletionGracePeriod)
+	err = queries.ScheduleAccountDeletion(ctx, pgstore.ScheduleAccountDeletionParams{
+		ID:                   id,
+		DeletionScheduledFor: pgtype.Timestamptz{Time: scheduledFor, Valid: true},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := revokeOtherSessions(ctx, queries, id, ""); err != nil {
+		return time.Time{}, err
+	}
+	if err := queries.DeleteApiTokensOfUser(ctx, id); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, err
+	}
+	return scheduledFor, nil
+}
+
+// confirmUser checks the password when there is one. Without it, only the
+// users of an identity provider who logged in recently are confirmed, as
+// their password is random and was never shown to them.
+func (us *UserServices) confirmUser(
+	ctx context.Context,
+	user pgstore.User,
+	password string,
+	loggedInAt time.Time,
+) error {
+	if password != "" {
+		err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+
+	identities, err := us.queries.ListUserIdentities(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if len(identities) == 0 {
+		return ErrInvalidCredentials
+	}
+	if time.Since(loggedInAt) > reauthenticationWindow {
+		return ErrReauthenticationRequired
+	}
+	return nil
+}
+
+// RestoreAccount cancels the deletion of an account during the grace period,
+// for a user who proves it is theirs with the same checks as a login.
+func (us *UserServices) RestoreAccount(ctx context.Context, email, password, ipAddress string) (uuid.UUID, error) {
+	user, err := us.checkCredentials(ctx, email, password, ipAddress)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	key := normalizeLoginEmail(email)
+	if err := suspension(user); err != nil {
+		recordLoginAttempt(ctx, us.queries, key, ipAddress, user.ID, loginOutcomeLocked)
+		return uuid.UUID{}, err
+	}
+
+	if err := us.queries.CancelAccountDeletion(ctx, user.ID); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	recordLoginAttempt(ctx, us.queries, key, ipAddress, user.ID, loginOutcomeSuccess)
+	return user.ID, nil
+}
+
+// RestoreAccountWithIdentity cancels the deletion of the account linked to
+// the identity, for users who log in through their provider.
+func (us *UserServices) RestoreAccountWithIdentity(ctx context.Context, id identity.Identity) (uuid.UUID, error) {
+	linked, err := us.queries.GetUserIdentity(ctx, pgstore.GetUserIdentityParams{
+		Provider: id.Provider,
+		Subject:  id.Subject,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.UUID{}, ErrUserNotFound
+		}
+		return uuid.UUID{}, err
+	}
+
+	user, err := us.GetUserById(ctx, linked.UserID)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if err := suspension(user); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if err := us.queries.CancelAccountDeletion(ctx, user.ID); err != nil {
+		return uuid.UUID{}, err
+	}
+	err = us.queries.TouchUserIdentity(ctx, pgstore.TouchUserIdentityParams{
+		ID:    linked.ID,
+		Email: id.Email,
+	})
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return user.ID, nil
+}
+
+// AccountPurger anonymizes the accounts whose grace period is over. Their
+// products and bids are kept, so auctions and sales stay consistent.
+type AccountPurger struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewAccountPurger(pool *pgxpool.Pool) AccountPurger {
+	return AccountPurger{
+		pool:    pool,
+		queries: pgstore.New(pool),
+	}
+}
+
+func (p AccountPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.purge(ctx); err != nil {
+			slog.Error("Failed to purge deleted accounts", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p AccountPurger) purge(ctx context.Context) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := p.queries.WithTx(tx)
+
+	accounts, err := queries.ClaimAccountsDueForDeletion(ctx, purgeBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		if err := anonymize(ctx, queries, account.ID, account.Email); err != nil {
+			return err
+		}
+		slog.Info("Account anonymized", "user_id", account.ID)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func anonymize(ctx context.Context, queries *pgstore.Queries, id uuid.UUID, email string) error {
+	deletions := []func(context.Context, uuid.UUID) error{
+		queries.DeleteUserTokensOfUser,
+		queries.DeleteRecoveryCodesOfUser,
+		queries.DeleteApiTokensOfUser,
+		queries.DeleteRefreshTokensOfUser,
+		queries.DeleteUserIdentitiesOfUser,
+		queries.DeleteWatchlistOfUser,
+		queries.DeleteNotificationsOfUser,
+		queries.DeleteWsTicketsOfUser,
+		queries.ClearFeedbackCommentsOfUser,
+		queries.DeleteCreditLimitsOfUser,
+	}
+	for _, deletion := range deletions {
+		if err := deletion(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	if err := revokeOtherSessions(ctx, queries, id, ""); err != nil {
+		return err
+	}
+
+	err := queries.DeleteLoginAttemptsOfUser(ctx, pgstore.DeleteLoginAttemptsOfUserParams{
+		UserID: pgtype.UUID{Bytes: id, Valid: true},
+		Email:  normalizeLoginEmail(email),
+	})
+	if err != nil {
+		return err
+	}
+
+	// The placeholders keep the unique constraints happy without saying
+	// anything about the user.
+	short := id.String()[:8]
+	return queries.AnonymizeUser(ctx, pgstore.AnonymizeUserParams{
+		ID:       id,
+		UserName: "deleted-" + short,
+		Email:    "deleted-" + id.String() + "@invalid",
+	})
+}
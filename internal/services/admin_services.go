@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/nathancamolez-dev/go-bid/internal/access"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+var (
+	ErrInsufficientRole = errors.New("insufficient role")
+	ErrInvalidRole      = errors.New("invalid role")
+)
+
+// AdminService backs the moderation routes.
+type AdminService struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewAdminService(pool *pgxpool.Pool) AdminService {
+	return AdminService{
+		pool:    pool,
+		queries: pgstore.New(pool),
+	}
+}
+
+type AdminUser struct {
+	ID               uuid.UUID   `json:"id"`
+	UserName         string      `json:"user_name"`
+	Email            string      `json:"email"`
+	Role             access.Role `json:"role"`
+	EmailVerified    bool        `json:"email_verified"`
+	SuspendedAt      *time.Time  `json:"suspended_at"`
+	SuspendedUntil   *time.Time  `json:"suspended_until"`
+	SuspensionReason string      `json:"suspension_reason,omitempty"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
+
+type BidHistoryEntry struct {
+	ID        uuid.UUID      `json:"id"`
+	UserID    uuid.UUID      `json:"user_id"`
+	UserName  string         `json:"user_name"`
+	Amount    money.Decimal  `json:"amount"`
+	Currency  money.Currency `json:"currency"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// GetRole returns the role of the user.
+func (as AdminService) GetRole(ctx context.Context, id uuid.UUID) (access.Role, error) {
+	user, err := as.queries.GetUserById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrUserNotFound
+		}
+		return "", err
+	}
+	return access.Role(user.Role), nil
+}
+
+// SearchUsers matches the query against user names and emails, newest
+// users first. An empty query lists every user.
+func (as AdminService) SearchUsers(ctx context.Context, query string, limit, offset int32) ([]AdminUser, error) {
+	rows, err := as.queries.SearchUsers(ctx, pgstore.SearchUsersParams{
+		Query:      query,
+		MaxResults: limit,
+		Skip:       offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]AdminUser, 0, len(rows))
+	for _, row := range rows {
+		user := AdminUser{
+			ID:            row.ID,
+			UserName:      row.UserName,
+			Email:         row.Email,
+			Role:          access.Role(row.Role),
+			EmailVerified: row.EmailVerifiedAt.Valid,
+			CreatedAt:     row.CreatedAt,
+		}
+		if row.SuspendedAt.Valid {
+			user.SuspendedAt = &row.SuspendedAt.Time
+			user.SuspensionReason = row.SuspensionReason
+		}
+		if row.SuspendedUntil.Valid {
+			user.SuspendedUntil = &row.SuspendedUntil.Time
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// SetRole changes the role of another user. Admins can't change their own
+// role, so there is always one left.
+func (as AdminService) SetRole(ctx context.Context, actorId, userId uuid.UUID, role access.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	if actorId == userId {
+		return ErrInsufficientRole
+	}
+
+	if _, err := as.GetRole(ctx, userId); err != nil {
+		return err
+	}
+	return as.queries.UpdateUserRole(ctx, pgstore.UpdateUserRoleParams{
+		ID:   userId,
+		Role: string(role),
+	})
+}
+
+// SuspendUser keeps a user from logging in and logs them out everywhere,
+// for the duration or for good when it is zero. Only users with a lower
+// role can be suspended. The caller is responsible for closing their live
+// connections.
+func (as AdminService) SuspendUser(
+	ctx context.Context,
+	actorId, userId uuid.UUID,
+	reason string,
+	duration time.Duration,
+) error {
+	tx, err := as.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := as.queries.WithTx(tx)
+
+	if err := ensureOutranks(ctx, queries, actorId, userId); err != nil {
+		return err
+	}
+
+	var until pgtype.Timestamptz
+	if duration > 0 {
+		until = pgtype.Timestamptz{Time: time.Now().Add(duration), Valid: true}
+	}
+
+	err = queries.SuspendUser(ctx, pgstore.SuspendUserParams{
+		ID:               userId,
+		SuspendedUntil:   until,
+		SuspensionReason: reason,
+	})
+	if err != nil {
+		return err
+	}
+	if err := revokeOtherSessions(ctx, queries, userId, ""); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as AdminService) UnsuspendUser(ctx context.Context, actorId, userId uuid.UUID) error {
+	if err := ensureOutranks(ctx, as.queries, actorId, userId); err != nil {
+		return err
+	}
+	return as.queries.UnsuspendUser(ctx, userId)
+}
+
+// CloseAuction ends an auction right away without a winner. The caller is
+// responsible for closing its room.
+func (as AdminService) CloseAuction(ctx context.Context, actorId, productId uuid.UUID, reason string) error {
+	closed, err := as.queries.CloseAuction(ctx, pgstore.CloseAuctionParams{
+		ID:          productId,
+		ClosedBy:    pgtype.UUID{Bytes: actorId, Valid: true},
+		CloseReason: reason,
+	})
+	if err != nil {
+		return err
+	}
+	if closed > 0 {
+		return nil
+	}
+
+	if _, err := as.queries.GetProductById(ctx, productId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	return ErrAuctionClosed
+}
+
+// GetBidHistory lists every bid placed on the product, newest first.
+func (as AdminService) GetBidHistory(ctx context.Context, productId uuid.UUID) ([]BidHistoryEntry, error) {
+	product, err := as.queries.GetProductById(ctx, productId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := as.queries.GetBidHistory(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+
+	bids := make([]BidHistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		bids = append(bids, BidHistoryEntry{
+			ID:        row.ID,
+			UserID:    row.UserID,
+			UserName:  row.UserName,
+			Amount:    row.BidAmount.Decimal(product.Currency),
+			Currency:  product.Currency,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return bids, nil
+}
+
+func ensureOutranks(ctx context.Context, queries *pgstore.Queries, actorId, userId uuid.UUID) error {
+	actor, err := queries.GetUserById(ctx, actorId)
+	if err != nil {
+		return err
+	}
+	user, err := queries.GetUserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if !access.Role(actor.Role).Outranks(access.Role(user.Role)) {
+		return ErrInsufficientRole
+	}
+	return nil
+}
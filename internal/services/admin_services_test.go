@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore/pgtest"
+)
+
+func TestSearchUsersTakesTheQueryLiterally(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	queries := pgstore.New(pool)
+	as := NewAdminService(pool)
+
+	createVerifiedUser(t, queries, "ann_lee")
+	createVerifiedUser(t, queries, "annxlee")
+	createVerifiedUser(t, queries, "bob")
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"ann_lee", "annxlee", "bob"}},
+		{"ANN", []string{"ann_lee", "annxlee"}},
+		{"_", []string{"ann_lee"}},
+		{"ann_", []string{"ann_lee"}},
+		{"%", nil},
+		{`\`, nil},
+		{"bob@example", []string{"bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			users, err := as.SearchUsers(context.Background(), tt.query, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, user := range users {
+				got = append(got, user.UserName)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
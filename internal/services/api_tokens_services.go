@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+var (
+	ErrApiTokenNotFound = errors.New("api token not found")
+	ErrTooManyApiTokens = errors.New("too many api tokens")
+)
+
+const (
+	// apiTokenPrefix makes leaked tokens easy to recognize and tells them
+	// apart from other bearer tokens.
+	apiTokenPrefix = "gobid_"
+	maxApiTokens   = 20
+)
+
+type ApiTokenService struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewApiTokenService(pool *pgxpool.Pool) ApiTokenService {
+	return ApiTokenService{
+		pool:    pool,
+		queries: pgstore.New(pool),
+	}
+}
+
+type ApiToken struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newApiToken(row pgstore.ApiToken) ApiToken {
+	token := ApiToken{
+		ID:        row.ID,
+		Name:      row.Name,
+		Scopes:    row.Scopes,
+		CreatedAt: row.CreatedAt,
+	}
+	if row.LastUsedAt.Valid {
+		token.LastUsedAt = &row.LastUsedAt.Time
+	}
+	if row.ExpiresAt.Valid {
+		token.ExpiresAt = &row.ExpiresAt.Time
+	}
+	return token
+}
+
+// HasScope reports whether the token was granted the scope.
+func (t ApiToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// IsApiToken tells api tokens apart from other bearer credentials.
+func IsApiToken(token string) bool {
+	return strings.HasPrefix(token, apiTokenPrefix)
+}
+
+// CreateApiToken returns the token, which is only known at this point, along
+// with its metadata. A zero expiresIn never expires.
+func (ats ApiTokenService) CreateApiToken(
+	ctx context.Context,
+	userId uuid.UUID,
+	name string,
+	scopes []string,
+	expiresIn time.Duration,
+) (string, ApiToken, error) {
+	tx, err := ats.pool.Begin(ctx)
+	if err != nil {
+		return "", ApiToken{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := ats.queries.WithTx(tx)
+
+	count, err := queries.CountApiTokens(ctx, userId)
+	if err != nil {
+		return "", ApiToken{}, err
+	}
+	if count >= maxApiTokens {
+		return "", ApiToken{}, ErrTooManyApiTokens
+	}
+
+	raw, _, err := newToken()
+	if err != nil {
+		return "", ApiToken{}, err
+	}
+	token := apiTokenPrefix + raw
+
+	var expiresAt pgtype.Timestamptz
+	if expiresIn > 0 {
+		expiresAt = pgtype.Timestamptz{Time: time.Now().Add(expiresIn), Valid: true}
+	}
+
+	row, err := queries.CreateApiToken(ctx, pgstore.CreateApiTokenParams{
+		UserID:    userId,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", ApiToken{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", ApiToken{}, err
+	}
+	return token, newApiToken(row), nil
+}
+
+// AuthenticateApiToken resolves a token to its owner, and keeps its
+// last_used_at up to date.
+func (ats ApiTokenService) AuthenticateApiToken(ctx context.Context, token string) (uuid.UUID, ApiToken, error) {
+	row, err := ats.queries.GetApiTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.UUID{}, ApiToken{}, ErrInvalidToken
+		}
+		return uuid.UUID{}, ApiToken{}, err
+	}
+
+	if !row.LastUsedAt.Valid || time.Since(row.LastUsedAt.Time) > lastSeenResolution {
+		if err := ats.queries.TouchApiToken(ctx, row.ID); err != nil {
+			return uuid.UUID{}, ApiToken{}, err
+		}
+	}
+	return row.UserID, newApiToken(row), nil
+}
+
+func (ats ApiTokenService) ListApiTokens(ctx context.Context, userId uuid.UUID) ([]ApiToken, error) {
+	rows, err := ats.queries.ListApiTokens(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]ApiToken, 0, len(rows))
+	for _, row := range rows {
+		tokens = append(tokens, newApiToken(row))
+	}
+	return tokens, nil
+}
+
+func (ats ApiTokenService) RevokeApiToken(ctx context.Context, userId, tokenId uuid.UUID) error {
+	deleted, err := ats.queries.DeleteApiToken(ctx, pgstore.DeleteApiTokenParams{
+		ID:     tokenId,
+		UserID: userId,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrApiTokenNotFound
+	}
+	return nil
+}
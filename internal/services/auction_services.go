@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"sync"
@@ -9,17 +10,25 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/ratelimit"
 )
 
 type MessageKind int
 
 const (
-	maxMessageSize = 512
-	readDeadline   = 60 * time.Second
-	writeWait      = 10 * time.Second
-	pingPeriod     = (readDeadline * 9) / 10
+	maxMessageSize       = 512
+	readDeadline         = 60 * time.Second
+	writeWait            = 10 * time.Second
+	pingPeriod           = (readDeadline * 9) / 10
+	ownershipCheckPeriod = 5 * time.Second
 )
 
+// clientMessageLimit is how many messages a connection can send, about two
+// bids a second with bursts of ten.
+var clientMessageLimit = ratelimit.Limit{Requests: 10, Window: 5 * time.Second}
+
 const (
 	//Requests
 	PlaceBid MessageKind = iota
@@ -34,18 +43,166 @@ const (
 	//Info
 	AuctionFinished
 	NewBidPlaced
+	AccountSuspended
+	AuctionState
+	RateLimited
 )
 
 type Message struct {
-	Message string      `json:"message,omitempty"`
-	Kind    MessageKind `json:"kind,omitempty"`
-	UserID  uuid.UUID   `json:"user_id,omitempty"`
-	Amount  float64     `json:"amount,omitempty"`
+	Message  string         `json:"message,omitempty"`
+	Kind     MessageKind    `json:"kind,omitempty"`
+	UserID   uuid.UUID      `json:"user_id,omitempty"`
+	Amount   money.Decimal  `json:"amount,omitempty"`
+	Currency money.Currency `json:"currency,omitempty"`
+
+	// Set on the way out for clients with a display currency.
+	DisplayAmount   money.Decimal  `json:"display_amount,omitempty"`
+	DisplayCurrency money.Currency `json:"display_currency,omitempty"`
+
+	// Only set on AuctionState messages.
+	Snapshot *AuctionSnapshot `json:"snapshot,omitempty"`
 }
 
 type AuctionLobby struct {
 	sync.Mutex
 	Rooms map[uuid.UUID]*AuctionRoom
+
+	InstanceId   uuid.UUID
+	PubSub       PubSub
+	Locker       RoomLocker
+	Rates        money.Rates
+	BidsServices BidsServices
+}
+
+// OpenRoom returns the running room for a product, starting it if this
+// instance has no copy of it yet. The room is removed from the lobby once
+// the auction ends.
+func (l *AuctionLobby) OpenRoom(
+	productId uuid.UUID,
+	currency money.Currency,
+	auctionEnd time.Time,
+) *AuctionRoom {
+	l.Lock()
+	defer l.Unlock()
+
+	if room, ok := l.Rooms[productId]; ok {
+		return room
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), auctionEnd)
+	room := NewAuctionRoom(ctx, productId, l.BidsServices)
+	room.Currency = currency
+	room.Rates = l.Rates
+	room.InstanceId = l.InstanceId
+	room.PubSub = l.PubSub
+	room.Locker = l.Locker
+	room.cancel = cancel
+	l.Rooms[productId] = room
+
+	go func() {
+		room.Run()
+		cancel()
+
+		l.Lock()
+		delete(l.Rooms, productId)
+		l.Unlock()
+	}()
+
+	return room
+}
+
+// CloseRoom ends the room of a product on every instance, for auctions
+// closed before their end.
+func (l *AuctionLobby) CloseRoom(ctx context.Context, productId uuid.UUID) {
+	l.closeLocalRoom(productId)
+
+	err := l.PubSub.Publish(ctx, RoomEvent{
+		Origin:  l.InstanceId,
+		RoomID:  productId,
+		Message: Message{Kind: AuctionFinished},
+	})
+	if err != nil {
+		slog.Error("Failed to publish room closing", "RoomID", productId, "error", err)
+	}
+}
+
+func (l *AuctionLobby) closeLocalRoom(productId uuid.UUID) {
+	l.Lock()
+	defer l.Unlock()
+
+	if room, ok := l.Rooms[productId]; ok && room.cancel != nil {
+		room.cancel()
+	}
+}
+
+// DisconnectUser closes the connections of a user to every room on every
+// instance, telling them why first.
+func (l *AuctionLobby) DisconnectUser(ctx context.Context, userId uuid.UUID, reason string) {
+	event := RoomEvent{
+		Origin:  l.InstanceId,
+		Target:  userId,
+		Message: Message{Kind: AccountSuspended, Message: reason},
+	}
+	l.disconnectLocalUser(event)
+
+	if err := l.PubSub.Publish(ctx, event); err != nil {
+		slog.Error("Failed to publish user disconnection", "user_id", userId, "error", err)
+	}
+}
+
+func (l *AuctionLobby) disconnectLocalUser(event RoomEvent) {
+	l.Lock()
+	rooms := make([]*AuctionRoom, 0, len(l.Rooms))
+	for _, room := range l.Rooms {
+		rooms = append(rooms, room)
+	}
+	l.Unlock()
+
+	for _, room := range rooms {
+		select {
+		case room.Events <- event:
+		case <-room.Context.Done():
+		}
+	}
+}
+
+// Listen subscribes to the events published by other instances and forwards
+// them to the local copy of their room until ctx is done.
+func (l *AuctionLobby) Listen(ctx context.Context) error {
+	events, err := l.PubSub.Subscribe(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for event := range events {
+			if event.Origin == l.InstanceId {
+				continue
+			}
+			switch event.Message.Kind {
+			case AuctionFinished:
+				l.closeLocalRoom(event.RoomID)
+				continue
+			case AccountSuspended:
+				l.disconnectLocalUser(event)
+				continue
+			}
+
+			l.Lock()
+			room, ok := l.Rooms[event.RoomID]
+			l.Unlock()
+			if !ok {
+				continue
+			}
+
+			select {
+			case room.Events <- event:
+			case <-room.Context.Done():
+			}
+		}
+	}()
+
+	return nil
 }
 
 type AuctionRoom struct {
@@ -55,15 +212,39 @@ type AuctionRoom struct {
 	Unregister chan *Client
 	Register   chan *Client
 	Clients    map[uuid.UUID]*Client
+	Events     chan RoomEvent
+	Currency   money.Currency
+	Rates      money.Rates
+
+	InstanceId uuid.UUID
+	PubSub     PubSub
+	Locker     RoomLocker
+	isOwner    bool
+	cancel     context.CancelFunc
 
 	BidsServices BidsServices
 }
 
+// registerClient sends the client its snapshot as the first message. The
+// current price is read again here, in the room loop, so bids accepted
+// before the client joined are in the snapshot and later ones reach it as
+// NewBidPlaced messages.
 func (r *AuctionRoom) registerClient(c *Client) {
 	slog.Info("New user connected", "Client", c)
 
 	r.Clients[c.UserId] = c
 
+	if c.Snapshot == nil {
+		return
+	}
+	snapshot := *c.Snapshot
+	amount, ok, err := r.BidsServices.HighestBidAmount(r.Context, r.Id)
+	if err != nil {
+		slog.Error("Failed to read the current price", "RoomID", r.Id, "error", err)
+	} else if ok {
+		snapshot.CurrentPrice = amount.Decimal(r.Currency)
+	}
+	c.Send <- Message{Kind: AuctionState, Snapshot: &snapshot}
 }
 
 func (r *AuctionRoom) unregisterClient(c *Client) {
@@ -76,49 +257,160 @@ func (r *AuctionRoom) broadcastMessage(m Message) {
 	slog.Info("New message recieved", "RoomID", r.Id, "message", m.Message, "user_id", m.UserID)
 	switch m.Kind {
 	case PlaceBid:
-		bid, err := r.BidsServices.PlaceBid(r.Context, r.Id, m.UserID, m.Amount)
-		if err != nil {
-			if errors.Is(err, ErrBidIsToLow) {
-				if client, ok := r.Clients[m.UserID]; ok {
-					client.Send <- Message{Kind: FailedToPlaceBid, Message: ErrBidIsToLow.Error()}
-				}
-			}
-			return
-		}
-
-		if client, ok := r.Clients[m.UserID]; ok {
-			client.Send <- Message{Kind: SuccessfullyPlacedBid, Message: "Successfully placed bid"}
+		// The owner may have gone down since the last ownership check, and
+		// a bid handed over then would reach nobody, so try to take over
+		// first.
+		if !r.isOwner {
+			r.claimOwnership()
 		}
-
-		for id, client := range r.Clients {
-			newBidMessage := Message{
-				Kind:    NewBidPlaced,
-				Message: "A new bid has been placed",
-				Amount:  bid.BidAmount,
-			}
-			if id == m.UserID {
-				continue
-			}
-			client.Send <- newBidMessage
+		if !r.isOwner {
+			// Another instance owns this room, hand the bid over to it.
+			r.publish(RoomEvent{Message: m})
+			return
 		}
-	case InvalidJSON:
+		r.placeBid(m)
+	case InvalidJSON, RateLimited:
 		client, ok := r.Clients[m.UserID]
 		if !ok {
 			slog.Info("Client not found ind hashmap", "user_id", m.UserID)
+			return
 		}
 		client.Send <- m
 	}
 }
 
+func (r *AuctionRoom) placeBid(m Message) {
+	bid, err := r.BidsServices.PlaceBid(r.Context, r.Id, m.UserID, m.Amount)
+	if err != nil {
+		if errors.Is(err, ErrBidIsToLow) || errors.Is(err, money.ErrInvalidAmount) ||
+			errors.Is(err, money.ErrTooPrecise) || errors.Is(err, ErrEmailNotVerified) ||
+			errors.Is(err, ErrAuctionClosed) || errors.Is(err, ErrAccountSuspended) ||
+			errors.Is(err, ErrCreditLimitExceeded) {
+			r.emit(RoomEvent{
+				Target:  m.UserID,
+				Message: Message{Kind: FailedToPlaceBid, Message: err.Error()},
+			})
+		}
+		return
+	}
+
+	r.emit(RoomEvent{
+		Target:  m.UserID,
+		Message: Message{Kind: SuccessfullyPlacedBid, Message: "Successfully placed bid"},
+	})
+
+	r.emit(RoomEvent{
+		Exclude: m.UserID,
+		Message: Message{
+			Kind:     NewBidPlaced,
+			Message:  "A new bid has been placed",
+			Amount:   bid.BidAmount.Decimal(r.Currency),
+			Currency: r.Currency,
+		},
+	})
+}
+
+// handleEvent processes an event published by another instance, or sent by
+// the lobby.
+func (r *AuctionRoom) handleEvent(e RoomEvent) {
+	switch e.Message.Kind {
+	case PlaceBid:
+		if r.isOwner {
+			r.placeBid(e.Message)
+		}
+	case AccountSuspended:
+		r.disconnectClient(e.Target, e.Message)
+	default:
+		r.deliver(e)
+	}
+}
+
+// disconnectClient hands the client its last message, after which its
+// write loop closes the connection.
+func (r *AuctionRoom) disconnectClient(userId uuid.UUID, m Message) {
+	client, ok := r.Clients[userId]
+	if !ok {
+		return
+	}
+	delete(r.Clients, userId)
+	client.Send <- m
+}
+
+// emit delivers an event to the local clients and to every other instance.
+func (r *AuctionRoom) emit(e RoomEvent) {
+	r.deliver(e)
+	r.publish(e)
+}
+
+func (r *AuctionRoom) publish(e RoomEvent) {
+	e.Origin = r.InstanceId
+	e.RoomID = r.Id
+	if err := r.PubSub.Publish(r.Context, e); err != nil {
+		slog.Error("Failed to publish room event", "RoomID", r.Id, "error", err)
+	}
+}
+
+func (r *AuctionRoom) deliver(e RoomEvent) {
+	if e.Target != uuid.Nil {
+		if client, ok := r.Clients[e.Target]; ok {
+			client.Send <- r.localize(e.Message, client)
+		}
+		return
+	}
+
+	for id, client := range r.Clients {
+		if id == e.Exclude {
+			continue
+		}
+		client.Send <- r.localize(e.Message, client)
+	}
+}
+
+// localize adds the amount in the client's display currency, when it has one
+// and a rate is configured for it.
+func (r *AuctionRoom) localize(m Message, c *Client) Message {
+	if m.Amount == "" || c.DisplayCurrency == "" || c.DisplayCurrency == m.Currency {
+		return m
+	}
+
+	amount, err := m.Amount.Money(m.Currency)
+	if err != nil {
+		return m
+	}
+	converted, ok := r.Rates.Convert(amount, m.Currency, c.DisplayCurrency)
+	if !ok {
+		return m
+	}
+
+	m.DisplayAmount = converted.Decimal(c.DisplayCurrency)
+	m.DisplayCurrency = c.DisplayCurrency
+	return m
+}
+
+func (r *AuctionRoom) claimOwnership() {
+	owner, err := r.Locker.TryAcquire(r.Context, r.Id)
+	if err != nil {
+		slog.Error("Failed to check room ownership", "RoomID", r.Id, "error", err)
+	}
+	if owner != r.isOwner {
+		slog.Info("Room ownership changed", "RoomID", r.Id, "owner", owner)
+	}
+	r.isOwner = owner
+}
+
 func (r *AuctionRoom) Run() {
 	slog.Info("Room stareted", "AuctionID", r.Id)
 
+	ownershipTicker := time.NewTicker(ownershipCheckPeriod)
 	defer func() {
-		close(r.Broadcast)
-		close(r.Register)
-		close(r.Unregister)
+		ownershipTicker.Stop()
+		if err := r.Locker.Release(context.Background(), r.Id); err != nil {
+			slog.Error("Failed to release room", "RoomID", r.Id, "error", err)
+		}
 	}()
 
+	r.claimOwnership()
+
 	for {
 		select {
 		case client := <-r.Register:
@@ -127,6 +419,10 @@ func (r *AuctionRoom) Run() {
 			r.unregisterClient(client)
 		case message := <-r.Broadcast:
 			r.broadcastMessage(message)
+		case event := <-r.Events:
+			r.handleEvent(event)
+		case <-ownershipTicker.C:
+			r.claimOwnership()
 		case <-r.Context.Done():
 			slog.Info("Auction has ended", "auctionID", r.Id)
 			for _, client := range r.Clients {
@@ -145,16 +441,24 @@ func NewAuctionRoom(ctx context.Context, id uuid.UUID, BidsServices BidsServices
 		Register:     make(chan *Client),
 		Unregister:   make(chan *Client),
 		Clients:      make(map[uuid.UUID]*Client),
+		Events:       make(chan RoomEvent, eventsBufferSize),
 		Context:      ctx,
+		PubSub:       NewLocalPubSub(),
+		Locker:       LocalRoomLocker{},
 		BidsServices: BidsServices,
 	}
 }
 
 type Client struct {
-	Room   *AuctionRoom
-	Conn   *websocket.Conn
-	Send   chan Message
-	UserId uuid.UUID
+	Room            *AuctionRoom
+	Conn            *websocket.Conn
+	Send            chan Message
+	UserId          uuid.UUID
+	DisplayCurrency money.Currency
+
+	// Snapshot is sent to the client once the room registers it, with the
+	// price at that moment.
+	Snapshot *AuctionSnapshot
 }
 
 func NewClient(room *AuctionRoom, conn *websocket.Conn, userId uuid.UUID) *Client {
@@ -166,9 +470,22 @@ func NewClient(room *AuctionRoom, conn *websocket.Conn, userId uuid.UUID) *Clien
 	}
 }
 
+// send hands a message to the room, giving up once the auction has ended.
+func (c *Client) send(m Message) bool {
+	select {
+	case c.Room.Broadcast <- m:
+		return true
+	case <-c.Room.Context.Done():
+		return false
+	}
+}
+
 func (c *Client) ReadEventLoop() {
 	defer func() {
-		c.Room.Unregister <- c
+		select {
+		case c.Room.Unregister <- c:
+		case <-c.Room.Context.Done():
+		}
 		c.Conn.Close()
 	}()
 
@@ -179,11 +496,20 @@ func (c *Client) ReadEventLoop() {
 		return nil
 	})
 
+	// Messages over the limit are dropped, telling the client once until
+	// it slows down.
+	limiter := ratelimit.NewBucket(clientMessageLimit)
+	throttled := false
+
 	for {
 		var m Message
-		m.UserID = c.UserId
 		err := c.Conn.ReadJSON(&m)
-		if err != nil {
+
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		invalid := errors.As(err, &syntaxErr) || errors.As(err, &typeErr) ||
+			errors.Is(err, money.ErrInvalidAmount)
+		if err != nil && !invalid {
 			if websocket.IsUnexpectedCloseError(
 				err,
 				websocket.CloseGoingAway,
@@ -191,13 +517,32 @@ func (c *Client) ReadEventLoop() {
 			) {
 				slog.Error("Unexpected close error", "error", err)
 			}
+			return
+		}
 
-			c.Room.Broadcast <- Message{Kind: InvalidJSON, Message: "This should be a valid json", UserID: m.UserID}
+		if result := limiter.Take(time.Now()); !result.Allowed {
+			if !throttled {
+				throttled = true
+				if !c.send(Message{Kind: RateLimited, Message: "Too many messages, slow down", UserID: c.UserId}) {
+					return
+				}
+			}
+			continue
+		}
+		throttled = false
 
+		if invalid {
+			if !c.send(Message{Kind: InvalidJSON, Message: "This should be a valid json", UserID: c.UserId}) {
+				return
+			}
 			continue
 		}
 
-		c.Room.Broadcast <- m
+		// The bidder is always the authenticated user, whatever the payload says.
+		m.UserID = c.UserId
+		if !c.send(m) {
+			return
+		}
 	}
 
 }
@@ -224,9 +569,22 @@ func (c *Client) WriteEventLoop() {
 				return
 			}
 
+			if message.Kind == AccountSuspended {
+				c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+				c.Conn.WriteJSON(message)
+				c.Conn.WriteMessage(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "account suspended"),
+				)
+				return
+			}
+
 			err := c.Conn.WriteJSON(message)
 			if err != nil {
-				c.Room.Unregister <- c
+				select {
+				case c.Room.Unregister <- c:
+				case <-c.Room.Context.Done():
+				}
 				return
 			}
 
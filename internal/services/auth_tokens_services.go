@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/nathancamolez-dev/go-bid/internal/jwt"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+	tokenIssuer     = "gobid"
+)
+
+// AuthTokenService issues the access and refresh tokens of the stateless
+// authentication mode. Access tokens are signed JWTs checked without hitting
+// the database, so they stay valid until they expire. Refresh tokens are
+// stored hashed and rotated on every use.
+type AuthTokenService struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+	keys    *jwt.Keyring
+}
+
+func NewAuthTokenService(pool *pgxpool.Pool, keys *jwt.Keyring) AuthTokenService {
+	return AuthTokenService{
+		pool:    pool,
+		queries: pgstore.New(pool),
+		keys:    keys,
+	}
+}
+
+// TokenPair follows the shape of an OAuth 2.0 token response.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// IssueTokens starts a new refresh token family for a user who just
+// authenticated.
+func (ats AuthTokenService) IssueTokens(ctx context.Context, userId uuid.UUID) (TokenPair, error) {
+	tx, err := ats.pool.Begin(ctx)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := ats.queries.WithTx(tx)
+
+	if err := queries.DeleteStaleRefreshTokens(ctx, userId); err != nil {
+		return TokenPair{}, err
+	}
+
+	pair, err := ats.issue(ctx, queries, userId, uuid.New())
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return TokenPair{}, err
+	}
+	return pair, nil
+}
+
+// RefreshTokens trades a refresh token for a new pair. A token that was
+// already used revokes its whole family and fails with ErrRefreshTokenReused.
+func (ats AuthTokenService) RefreshTokens(ctx context.Context, refreshToken string) (TokenPair, error) {
+	tx, err := ats.pool.Begin(ctx)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := ats.queries.WithTx(tx)
+
+	// The row lock makes concurrent refreshes with the same token wait, so
+	// only one of them can use it.
+	token, err := queries.GetRefreshTokenByHashForUpdate(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TokenPair{}, ErrInvalidToken
+		}
+		return TokenPair{}, err
+	}
+
+	if token.RevokedAt.Valid || time.Now().After(token.ExpiresAt) {
+		return TokenPair{}, ErrInvalidToken
+	}
+
+	if token.UsedAt.Valid {
+		if err := queries.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+			return TokenPair{}, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrRefreshTokenReused
+	}
+
+	if err := ensureActive(ctx, queries, token.UserID); err != nil {
+		return TokenPair{}, err
+	}
+
+	if err := queries.UseRefreshToken(ctx, token.ID); err != nil {
+		return TokenPair{}, err
+	}
+
+	pair, err := ats.issue(ctx, queries, token.UserID, token.FamilyID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return TokenPair{}, err
+	}
+	return pair, nil
+}
+
+// RevokeRefreshToken logs out the family of the token. Unknown tokens are
+// ignored, as there is nothing left to revoke.
+func (ats AuthTokenService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	tx, err := ats.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := ats.queries.WithTx(tx)
+
+	token, err := queries.GetRefreshTokenByHashForUpdate(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if err := queries.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// AuthenticateAccessToken returns the user an access token was issued to.
+func (ats AuthTokenService) AuthenticateAccessToken(token string) (uuid.UUID, error) {
+	claims, err := ats.keys.Verify(token, time.Now())
+	if err != nil {
+		return uuid.UUID{}, ErrInvalidToken
+	}
+	if claims.Issuer != tokenIssuer {
+		return uuid.UUID{}, ErrInvalidToken
+	}
+
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.UUID{}, ErrInvalidToken
+	}
+	return userId, nil
+}
+
+func (ats AuthTokenService) issue(
+	ctx context.Context,
+	queries *pgstore.Queries,
+	userId, familyId uuid.UUID,
+) (TokenPair, error) {
+	refreshToken, hash, err := newToken()
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	err = queries.CreateRefreshToken(ctx, pgstore.CreateRefreshTokenParams{
+		UserID:    userId,
+		FamilyID:  familyId,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	now := time.Now()
+	accessToken, err := ats.keys.Sign(jwt.Claims{
+		Issuer:    tokenIssuer,
+		Subject:   userId.String(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(accessTokenTTL).Unix(),
+		ID:        uuid.NewString(),
+	})
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+// States of the products a user bid on.
+const (
+	BidWinning = "winning"
+	BidOutbid  = "outbid"
+	BidWon     = "won"
+	BidLost    = "lost"
+	BidClosed  = "closed"
+)
+
+var BidStates = []string{BidWinning, BidOutbid, BidWon, BidLost, BidClosed}
+
+type BidActivity struct {
+	ProductID    uuid.UUID      `json:"product_id"`
+	ProductName  string         `json:"product_name"`
+	State        string         `json:"state"`
+	MyMaxBid     money.Decimal  `json:"my_max_bid"`
+	MyBids       int64          `json:"my_bids"`
+	LastBidAt    time.Time      `json:"last_bid_at"`
+	CurrentPrice money.Decimal  `json:"current_price"`
+	Currency     money.Currency `json:"currency"`
+	AuctionEnd   time.Time      `json:"auction_end"`
+	TimeLeft     int64          `json:"time_left_seconds"`
+}
+
+// ListBidActivity returns one entry per product the user bid on, those in
+// the state only when it isn't empty, latest auction end first.
+func (bs *BidsServices) ListBidActivity(
+	ctx context.Context,
+	userId uuid.UUID,
+	state string,
+	limit, offset int32,
+) ([]BidActivity, error) {
+	rows, err := bs.queries.ListBidderActivity(ctx, pgstore.ListBidderActivityParams{
+		UserID:     userId,
+		State:      state,
+		MaxResults: limit,
+		Skip:       offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	activity := make([]BidActivity, 0, len(rows))
+	for _, row := range rows {
+		entry := BidActivity{
+			ProductID:    row.ID,
+			ProductName:  row.ProductName,
+			State:        row.State,
+			MyMaxBid:     money.Money(row.MyMaxBid).Decimal(row.Currency),
+			MyBids:       row.MyBids,
+			LastBidAt:    row.LastBidAt,
+			CurrentPrice: money.Money(row.CurrentPrice).Decimal(row.Currency),
+			Currency:     row.Currency,
+			AuctionEnd:   row.AuctionEnd,
+		}
+		if row.State == BidWinning || row.State == BidOutbid {
+			entry.TimeLeft = int64(max(row.AuctionEnd.Sub(now), 0).Seconds())
+		}
+		activity = append(activity, entry)
+	}
+	return activity, nil
+}
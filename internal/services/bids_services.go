@@ -2,12 +2,15 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/nathancamolez-dev/go-bid/internal/money"
 	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
 )
 
@@ -18,6 +21,8 @@ type BidsServices struct {
 
 var ErrBidIsToLow = errors.New("the bid value is too low")
 
+var ErrAuctionClosed = errors.New("the auction is closed")
+
 func NewBidsServices(pool *pgxpool.Pool) BidsServices {
 	return BidsServices{
 		pool:    pool,
@@ -25,19 +30,51 @@ func NewBidsServices(pool *pgxpool.Pool) BidsServices {
 	}
 }
 
+// PlaceBid stores a bid if it beats the base price and the current highest
+// bid. The amount is read in the product's currency and must not have more
+// decimal places than that currency allows. The previous highest bidder is
+// notified through the outbox in the same transaction. The product row is
+// locked for the duration of the transaction, so concurrent bids for the
+// same product are checked one after the other and only strictly
+// increasing amounts are ever stored.
 func (bs *BidsServices) PlaceBid(
 	ctx context.Context,
 	product_id, bidder_id uuid.UUID,
-	amount float64,
+	decimal money.Decimal,
 ) (pgstore.Bid, error) {
-	product, err := bs.queries.GetProductById(ctx, product_id)
+	tx, err := bs.pool.Begin(ctx)
+	if err != nil {
+		return pgstore.Bid{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := bs.queries.WithTx(tx)
+
+	if err := ensureVerified(ctx, queries, bidder_id); err != nil {
+		return pgstore.Bid{}, err
+	}
+	if err := ensureActive(ctx, queries, bidder_id); err != nil {
+		return pgstore.Bid{}, err
+	}
+
+	product, err := queries.GetProductByIdForUpdate(ctx, product_id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return pgstore.Bid{}, err
+			return pgstore.Bid{}, ErrProductNotFound
 		}
+		return pgstore.Bid{}, err
+	}
+
+	if !product.AuctionEnd.After(time.Now()) {
+		return pgstore.Bid{}, ErrAuctionClosed
 	}
 
-	highestBid, err := bs.queries.GetHighestBidByProductId(ctx, product_id)
+	amount, err := decimal.Money(product.Currency)
+	if err != nil {
+		return pgstore.Bid{}, err
+	}
+
+	highestBid, err := queries.GetHighestBidByProductId(ctx, product_id)
 	if err != nil {
 		if !errors.Is(err, pgx.ErrNoRows) {
 			return pgstore.Bid{}, err
@@ -48,7 +85,32 @@ func (bs *BidsServices) PlaceBid(
 		return pgstore.Bid{}, ErrBidIsToLow
 	}
 
-	highestBid, err = bs.queries.CreateBid(ctx, pgstore.CreateBidParams{
+	if err := checkCreditLimit(ctx, queries, bidder_id, product, amount); err != nil {
+		return pgstore.Bid{}, err
+	}
+
+	if highestBid.ID != uuid.Nil && highestBid.UserID != bidder_id {
+		payload, err := json.Marshal(OutbidPayload{
+			ProductID:   product.ID,
+			ProductName: product.ProductName,
+			Amount:      amount.Decimal(product.Currency),
+			Currency:    product.Currency,
+		})
+		if err != nil {
+			return pgstore.Bid{}, err
+		}
+
+		err = queries.CreateNotification(ctx, pgstore.CreateNotificationParams{
+			UserID:  highestBid.UserID,
+			Kind:    OutbidNotification,
+			Payload: payload,
+		})
+		if err != nil {
+			return pgstore.Bid{}, err
+		}
+	}
+
+	highestBid, err = queries.CreateBid(ctx, pgstore.CreateBidParams{
 		ProductID: product_id,
 		UserID:    bidder_id,
 		BidAmount: amount,
@@ -57,5 +119,25 @@ func (bs *BidsServices) PlaceBid(
 		return pgstore.Bid{}, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return pgstore.Bid{}, err
+	}
+
 	return highestBid, nil
 }
+
+// HighestBidAmount returns the amount of the highest bid on a product, and
+// false when it has no bids yet.
+func (bs *BidsServices) HighestBidAmount(
+	ctx context.Context,
+	product_id uuid.UUID,
+) (money.Money, bool, error) {
+	bid, err := bs.queries.GetHighestBidByProductId(ctx, product_id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return bid.BidAmount, true, nil
+}
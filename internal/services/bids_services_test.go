@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore/pgtest"
+)
+
+func createVerifiedUser(t *testing.T, queries *pgstore.Queries, name string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+
+	id, err := queries.CreateUser(ctx, pgstore.CreateUserParams{
+		UserName:     name,
+		Email:        name + "@example.com",
+		PasswordHash: []byte("not a hash"),
+		Bio:          "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queries.MarkEmailVerified(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// TestPlaceBidConcurrently races bids of increasing amounts, sent in random
+// order, on one product. Bids must be checked one after the other, so each
+// accepted bid beats the one accepted before it, and its bidder is the one
+// the next accepted bid outbids.
+func TestPlaceBidConcurrently(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	queries := pgstore.New(pool)
+	bs := NewBidsServices(pool)
+	ctx := context.Background()
+
+	seller := createVerifiedUser(t, queries, "seller")
+	productId, err := queries.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:    seller,
+		ProductName: "Lamp",
+		Description: "A lamp",
+		Baseprice:   100,
+		Currency:    money.DefaultCurrency,
+		AuctionEnd:  time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const bidders = 20
+	bidderOf := make(map[money.Money]uuid.UUID, bidders)
+	amounts := make([]money.Money, 0, bidders)
+	for i := range bidders {
+		amount := money.Money(100 * (i + 1))
+		bidderOf[amount] = createVerifiedUser(t, queries, fmt.Sprintf("bidder%d", i))
+		amounts = append(amounts, amount)
+	}
+	rand.Shuffle(len(amounts), func(i, j int) {
+		amounts[i], amounts[j] = amounts[j], amounts[i]
+	})
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted []money.Money
+		start    = make(chan struct{})
+	)
+	for _, amount := range amounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			decimal := amount.Decimal(money.DefaultCurrency)
+			_, err := bs.PlaceBid(ctx, productId, bidderOf[amount], decimal)
+			if err != nil {
+				if !errors.Is(err, ErrBidIsToLow) {
+					t.Errorf("bid of %s: %v", decimal, err)
+				}
+				return
+			}
+			mu.Lock()
+			accepted = append(accepted, amount)
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	sort.Slice(accepted, func(i, j int) bool { return accepted[i] < accepted[j] })
+	if len(accepted) == 0 || accepted[len(accepted)-1] != 100*bidders {
+		t.Fatalf("accepted bids %v, want the highest one among them", accepted)
+	}
+
+	var stored []money.Money
+	rows, err := pool.Query(ctx,
+		"SELECT bid_amount FROM bids WHERE product_id = $1 ORDER BY bid_amount", productId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var amount money.Money
+		if err := rows.Scan(&amount); err != nil {
+			t.Fatal(err)
+		}
+		stored = append(stored, amount)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(stored) != fmt.Sprint(accepted) {
+		t.Fatalf("stored bids %v, want the accepted ones %v", stored, accepted)
+	}
+
+	// Bids checked against a stale highest bid would outbid the same bidder
+	// twice, or a bidder who never held the highest bid.
+	outbid := make(map[uuid.UUID]money.Decimal)
+	rows, err = pool.Query(ctx,
+		"SELECT user_id, payload->>'amount' FROM notifications WHERE kind = $1", OutbidNotification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var (
+			userId uuid.UUID
+			amount money.Decimal
+		)
+		if err := rows.Scan(&userId, &amount); err != nil {
+			t.Fatal(err)
+		}
+		if previous, ok := outbid[userId]; ok {
+			t.Errorf("bidder outbid twice, by %s and %s", previous, amount)
+		}
+		outbid[userId] = amount
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outbid) != len(accepted)-1 {
+		t.Errorf("got %d outbid notifications, want %d", len(outbid), len(accepted)-1)
+	}
+	for i, amount := range accepted[:len(accepted)-1] {
+		next := accepted[i+1].Decimal(money.DefaultCurrency)
+		if got := outbid[bidderOf[amount]]; got != next {
+			t.Errorf("bidder of %s outbid by %q, want %s",
+				amount.Decimal(money.DefaultCurrency), got, next)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+var ErrCreditLimitExceeded = errors.New("the bid would exceed your credit limit")
+
+// CreditLimitError is returned, matching ErrCreditLimitExceeded, for bids
+// over what the user can still owe in the currency.
+type CreditLimitError struct {
+	Available money.Decimal
+	Currency  money.Currency
+}
+
+func (e CreditLimitError) Error() string {
+	return fmt.Sprintf("%s, %s %s available", ErrCreditLimitExceeded, e.Available, e.Currency)
+}
+
+func (e CreditLimitError) Is(target error) bool {
+	return target == ErrCreditLimitExceeded
+}
+
+// Credit is the limit of a user in a currency, and how much of it the
+// auctions they lead or won take.
+type Credit struct {
+	Currency  money.Currency `json:"currency"`
+	Limit     money.Decimal  `json:"limit"`
+	Exposure  money.Decimal  `json:"exposure"`
+	Available money.Decimal  `json:"available"`
+}
+
+// checkCreditLimit fails when leading the product with amount would take the
+// user over their limit in its currency. The bid replaces the user's own
+// lead on the product, so it is left out of the exposure. Users without a
+// limit in the currency, as every account starts, bid without one.
+func checkCreditLimit(
+	ctx context.Context,
+	queries *pgstore.Queries,
+	userId uuid.UUID,
+	product pgstore.Product,
+	amount money.Money,
+) error {
+	limit, err := queries.GetCreditLimitForUpdate(ctx, pgstore.GetCreditLimitForUpdateParams{
+		UserID:   userId,
+		Currency: product.Currency,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	exposure, err := queries.GetCreditExposure(ctx, pgstore.GetCreditExposureParams{
+		Currency:          product.Currency,
+		ExcludedProductID: product.ID,
+		UserID:            userId,
+	})
+	if err != nil {
+		return err
+	}
+
+	if money.Money(exposure)+amount > limit {
+		return CreditLimitError{
+			Available: max(limit-money.Money(exposure), 0).Decimal(product.Currency),
+			Currency:  product.Currency,
+		}
+	}
+	return nil
+}
+
+// GetCredit returns the credit limits of the user, with what they can still
+// bid in each currency.
+func (us *UserServices) GetCredit(ctx context.Context, userId uuid.UUID) ([]Credit, error) {
+	limits, err := us.queries.ListCreditLimits(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	credit := make([]Credit, 0, len(limits))
+	for _, limit := range limits {
+		exposure, err := us.queries.GetCreditExposure(ctx, pgstore.GetCreditExposureParams{
+			Currency:          limit.Currency,
+			ExcludedProductID: uuid.Nil,
+			UserID:            userId,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		credit = append(credit, Credit{
+			Currency:  limit.Currency,
+			Limit:     limit.Amount.Decimal(limit.Currency),
+			Exposure:  money.Money(exposure).Decimal(limit.Currency),
+			Available: max(limit.Amount-money.Money(exposure), 0).Decimal(limit.Currency),
+		})
+	}
+	return credit, nil
+}
+
+// SetCreditLimit sets the credit limit of a user in a currency, or removes
+// it when amount is nil. Lowering a limit doesn't affect the bids already
+// placed.
+func (as AdminService) SetCreditLimit(
+	ctx context.Context,
+	userId uuid.UUID,
+	currency money.Currency,
+	amount *money.Money,
+) error {
+	if _, err := as.GetRole(ctx, userId); err != nil {
+		return err
+	}
+
+	if amount == nil {
+		return as.queries.DeleteCreditLimit(ctx, pgstore.DeleteCreditLimitParams{
+			UserID:   userId,
+			Currency: currency,
+		})
+	}
+	return as.queries.SetCreditLimit(ctx, pgstore.SetCreditLimitParams{
+		UserID:   userId,
+		Currency: currency,
+		Amount:   *amount,
+	})
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+var (
+	ErrNotSold              = errors.New("the product was not sold")
+	ErrNotAuctionParty      = errors.New("only the buyer and the seller can leave feedback")
+	ErrFeedbackWindowClosed = errors.New("the feedback window is closed")
+	ErrFeedbackAlreadyLeft  = errors.New("feedback was already left for this sale")
+	ErrAuctionNotEnded      = errors.New("the auction has not ended yet")
+)
+
+// Parts a user plays in a sale.
+const (
+	FeedbackRoleBuyer  = "buyer"
+	FeedbackRoleSeller = "seller"
+)
+
+// feedbackWindow is how long after the end of an auction its buyer and
+// seller can rate each other.
+const feedbackWindow = 30 * 24 * time.Hour
+
+type FeedbackService struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewFeedbackService(pool *pgxpool.Pool) FeedbackService {
+	return FeedbackService{
+		pool:    pool,
+		queries: pgstore.New(pool),
+	}
+}
+
+type Feedback struct {
+	ID           uuid.UUID `json:"id"`
+	ProductID    uuid.UUID `json:"product_id"`
+	ProductName  string    `json:"product_name"`
+	Role         string    `json:"role"`
+	FromUserID   uuid.UUID `json:"from_user_id"`
+	FromUserName string    `json:"from_user_name"`
+	Rating       int16     `json:"rating"`
+	Comment      string    `json:"comment"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RatingSummary is the number of ratings and their average, from 1 to 5,
+// or 0 without ratings.
+type RatingSummary struct {
+	Ratings int64   `json:"ratings"`
+	Average float64 `json:"average"`
+}
+
+// Reputation sums up the ratings a user received, overall and by the part
+// they played in the sales.
+type Reputation struct {
+	RatingSummary
+	AsSeller RatingSummary `json:"as_seller"`
+	AsBuyer  RatingSummary `json:"as_buyer"`
+}
+
+func getReputation(ctx context.Context, queries *pgstore.Queries, userId uuid.UUID) (Reputation, error) {
+	row, err := queries.GetReputation(ctx, userId)
+	if err != nil {
+		return Reputation{}, err
+	}
+	return Reputation{
+		RatingSummary: RatingSummary{Ratings: row.Ratings, Average: roundRating(row.Average)},
+		AsSeller:      RatingSummary{Ratings: row.SellerRatings, Average: roundRating(row.SellerAverage)},
+		AsBuyer:       RatingSummary{Ratings: row.BuyerRatings, Average: roundRating(row.BuyerAverage)},
+	}, nil
+}
+
+func roundRating(average float64) float64 {
+	return math.Round(average*100) / 100
+}
+
+func (fs FeedbackService) GetReputation(ctx context.Context, userId uuid.UUID) (Reputation, error) {
+	return getReputation(ctx, fs.queries, userId)
+}
+
+// LeaveFeedback rates the other party of a sale. The buyer is the highest
+// bidder of an auction that ended without being closed by a moderator, and
+// each side can rate once within feedbackWindow of the end.
+func (fs FeedbackService) LeaveFeedback(
+	ctx context.Context,
+	productId, userId uuid.UUID,
+	rating int16,
+	comment string,
+) error {
+	if err := ensureActive(ctx, fs.queries, userId); err != nil {
+		return err
+	}
+
+	product, err := fs.queries.GetProductById(ctx, productId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+
+	now := time.Now()
+	if product.ClosedAt.Valid {
+		return ErrNotSold
+	}
+	if product.AuctionEnd.After(now) {
+		return ErrAuctionNotEnded
+	}
+	if now.After(product.AuctionEnd.Add(feedbackWindow)) {
+		return ErrFeedbackWindowClosed
+	}
+
+	winningBid, err := fs.queries.GetHighestBidByProductId(ctx, productId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotSold
+		}
+		return err
+	}
+
+	params := pgstore.CreateFeedbackParams{
+		ProductID:  productId,
+		FromUserID: userId,
+		Rating:     rating,
+		Comment:    comment,
+	}
+	switch userId {
+	case product.SellerID:
+		params.Role = FeedbackRoleSeller
+		params.ToUserID = winningBid.UserID
+	case winningBid.UserID:
+		params.Role = FeedbackRoleBuyer
+		params.ToUserID = product.SellerID
+	default:
+		return ErrNotAuctionParty
+	}
+
+	created, err := fs.queries.CreateFeedback(ctx, params)
+	if err != nil {
+		return err
+	}
+	if created == 0 {
+		return ErrFeedbackAlreadyLeft
+	}
+	return nil
+}
+
+// ListFeedback returns the feedback a user received, newest first.
+func (fs FeedbackService) ListFeedback(ctx context.Context, userId uuid.UUID, limit, offset int32) ([]Feedback, error) {
+	rows, err := fs.queries.ListFeedbackForUser(ctx, pgstore.ListFeedbackForUserParams{
+		ToUserID: userId,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	feedback := make([]Feedback, 0, len(rows))
+	for _, row := range rows {
+		feedback = append(feedback, Feedback{
+			ID:           row.ID,
+			ProductID:    row.ProductID,
+			ProductName:  row.ProductName,
+			Role:         row.Role,
+			FromUserID:   row.FromUserID,
+			FromUserName: row.FromUserName,
+			Rating:       row.Rating,
+			Comment:      row.Comment,
+			CreatedAt:    row.CreatedAt,
+		})
+	}
+	return feedback, nil
+}
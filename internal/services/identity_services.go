@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/nathancamolez-dev/go-bid/internal/identity"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+var (
+	ErrIdentityEmailUnverified = errors.New("the provider did not verify the email")
+	ErrIdentityEmailTaken      = errors.New("an account already uses the email")
+)
+
+// userNameAttempts is how many suffixed user names are tried when the one
+// derived from the identity is taken.
+const userNameAttempts = 5
+
+var userNameDisallowed = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// LoginWithIdentity returns the user linked to the identity. The first login
+// links it to the account with the same email, when both sides verified it,
+// or creates an account otherwise.
+func (us *UserServices) LoginWithIdentity(ctx context.Context, id identity.Identity) (uuid.UUID, error) {
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	linked, err := queries.GetUserIdentity(ctx, pgstore.GetUserIdentityParams{
+		Provider: id.Provider,
+		Subject:  id.Subject,
+	})
+	if err == nil {
+		if err := ensureActive(ctx, queries, linked.UserID); err != nil {
+			return uuid.UUID{}, err
+		}
+		err = queries.TouchUserIdentity(ctx, pgstore.TouchUserIdentityParams{
+			ID:    linked.ID,
+			Email: id.Email,
+		})
+		if err != nil {
+			return uuid.UUID{}, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return uuid.UUID{}, err
+		}
+		return linked.UserID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.UUID{}, err
+	}
+
+	if id.Email == "" || !id.EmailVerified {
+		return uuid.UUID{}, ErrIdentityEmailUnverified
+	}
+
+	var userId uuid.UUID
+	user, err := queries.GetUserByEmail(ctx, id.Email)
+	switch {
+	case err == nil:
+		// Linking to an unverified account would hand it to whoever signed
+		// up with someone else's email.
+		if !user.EmailVerifiedAt.Valid {
+			return uuid.UUID{}, ErrIdentityEmailTaken
+		}
+		if err := suspension(user); err != nil {
+			return uuid.UUID{}, err
+		}
+		// Accounts scheduled for deletion only come back through a restore.
+		if err := pendingDeletion(user); err != nil {
+			return uuid.UUID{}, err
+		}
+		userId = user.ID
+	case errors.Is(err, pgx.ErrNoRows):
+		userId, err = createIdentityUser(ctx, queries, id)
+		if err != nil {
+			return uuid.UUID{}, err
+		}
+	default:
+		return uuid.UUID{}, err
+	}
+
+	err = queries.CreateUserIdentity(ctx, pgstore.CreateUserIdentityParams{
+		UserID:   userId,
+		Provider: id.Provider,
+		Subject:  id.Subject,
+		Email:    id.Email,
+	})
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.UUID{}, err
+	}
+	return userId, nil
+}
+
+// createIdentityUser signs up the user of an identity. The password is
+// random, so it can only be used after going through the password reset.
+func createIdentityUser(ctx context.Context, queries *pgstore.Queries, id identity.Identity) (uuid.UUID, error) {
+	password, _, err := newToken()
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	base := identityUserName(id)
+	userName := base
+	for range userNameAttempts {
+		userId, err := queries.CreateIdentityUser(ctx, pgstore.CreateIdentityUserParams{
+			UserName:     userName,
+			Email:        id.Email,
+			PasswordHash: hash,
+		})
+		if err == nil {
+			return userId, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return uuid.UUID{}, err
+		}
+
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return uuid.UUID{}, err
+		}
+		userName = fmt.Sprintf("%s%04d", base, suffix)
+	}
+	return uuid.UUID{}, ErrDuplicatedEmailOrUsername
+}
+
+func identityUserName(id identity.Identity) string {
+	name := id.Name
+	if name == "" {
+		name, _, _ = strings.Cut(id.Email, "@")
+	}
+
+	name = userNameDisallowed.ReplaceAllString(name, "")
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	if name == "" {
+		name = "user"
+	}
+	return name
+}
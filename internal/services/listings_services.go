@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+// Statuses of a listing. Auctions start as soon as their product is
+// created, so no listing is scheduled until products get a start time.
+// Ended is only a filter, for every listing that is sold, unsold or closed.
+const (
+	ListingScheduled = "scheduled"
+	ListingLive      = "live"
+	ListingEnded     = "ended"
+	ListingSold      = "sold"
+	ListingUnsold    = "unsold"
+	ListingClosed    = "closed"
+)
+
+var ListingStatuses = []string{
+	ListingScheduled,
+	ListingLive,
+	ListingEnded,
+	ListingSold,
+	ListingUnsold,
+	ListingClosed,
+}
+
+type Listing struct {
+	ProductID     uuid.UUID      `json:"product_id"`
+	ProductName   string         `json:"product_name"`
+	Status        string         `json:"status"`
+	CurrentPrice  money.Decimal  `json:"current_price"`
+	Currency      money.Currency `json:"currency"`
+	Bids          int64          `json:"bids"`
+	UniqueBidders int64          `json:"unique_bidders"`
+	Watchers      int64          `json:"watchers"`
+	AuctionEnd    time.Time      `json:"auction_end"`
+	TimeLeft      int64          `json:"time_left_seconds"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+type Revenue struct {
+	Amount   money.Decimal  `json:"amount"`
+	Currency money.Currency `json:"currency"`
+}
+
+// SellerDashboard pages through the listings of a seller. The totals cover
+// every listing whatever the page and the status filter.
+type SellerDashboard struct {
+	Listings []Listing `json:"listings"`
+
+	Scheduled int64 `json:"scheduled"`
+	Live      int64 `json:"live"`
+	Ended     int64 `json:"ended"`
+	Sold      int64 `json:"sold"`
+	Unsold    int64 `json:"unsold"`
+	Closed    int64 `json:"closed"`
+
+	// Revenue is by currency, as amounts in different currencies can't be
+	// added up.
+	Revenue []Revenue `json:"revenue"`
+	// SellThroughRate is the share of finished listings that sold, from 0
+	// to 1.
+	SellThroughRate float64 `json:"sell_through_rate"`
+}
+
+// GetSellerDashboard lists the products of a seller, those with the status
+// only when it isn't empty, latest auction end first.
+func (ps ProductService) GetSellerDashboard(
+	ctx context.Context,
+	sellerId uuid.UUID,
+	status string,
+	limit, offset int32,
+) (SellerDashboard, error) {
+	rows, err := ps.queries.ListSellerListings(ctx, pgstore.ListSellerListingsParams{
+		SellerID:   sellerId,
+		Status:     status,
+		MaxResults: limit,
+		Skip:       offset,
+	})
+	if err != nil {
+		return SellerDashboard{}, err
+	}
+
+	counts, err := ps.queries.CountSellerListingsByStatus(ctx, sellerId)
+	if err != nil {
+		return SellerDashboard{}, err
+	}
+
+	revenue, err := ps.queries.GetSellerRevenue(ctx, sellerId)
+	if err != nil {
+		return SellerDashboard{}, err
+	}
+
+	dashboard := SellerDashboard{
+		Listings: make([]Listing, 0, len(rows)),
+		Live:     counts.Live,
+		Ended:    counts.Sold + counts.Unsold + counts.Closed,
+		Sold:     counts.Sold,
+		Unsold:   counts.Unsold,
+		Closed:   counts.Closed,
+		Revenue:  make([]Revenue, 0, len(revenue)),
+	}
+
+	now := time.Now()
+	for _, row := range rows {
+		listing := Listing{
+			ProductID:     row.ID,
+			ProductName:   row.ProductName,
+			Status:        row.Status,
+			CurrentPrice:  money.Money(row.CurrentPrice).Decimal(row.Currency),
+			Currency:      row.Currency,
+			Bids:          row.BidCount,
+			UniqueBidders: row.UniqueBidders,
+			Watchers:      row.Watchers,
+			AuctionEnd:    row.AuctionEnd,
+			CreatedAt:     row.CreatedAt,
+		}
+		if row.Status == ListingLive {
+			listing.TimeLeft = int64(max(row.AuctionEnd.Sub(now), 0).Seconds())
+		}
+		dashboard.Listings = append(dashboard.Listings, listing)
+	}
+
+	for _, row := range revenue {
+		dashboard.Revenue = append(dashboard.Revenue, Revenue{
+			Amount:   money.Money(row.Revenue).Decimal(row.Currency),
+			Currency: row.Currency,
+		})
+	}
+
+	if dashboard.Ended > 0 {
+		rate := float64(counts.Sold) / float64(dashboard.Ended)
+		dashboard.SellThroughRate = math.Round(rate*1000) / 1000
+	}
+
+	return dashboard, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+var ErrAccountLocked = errors.New("too many failed login attempts")
+
+// AccountLockedError is returned, matching ErrAccountLocked, while an email
+// or an IP address has to wait before trying to log in again.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e AccountLockedError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrAccountLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e AccountLockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+const (
+	loginOutcomeSuccess = "success"
+	loginOutcomeFailure = "failure"
+	loginOutcomeLocked  = "locked"
+
+	// Failures allowed before backing off. IP addresses get more room, as
+	// they may be shared by many users.
+	freeEmailFailures = 5
+	freeIPFailures    = 20
+
+	loginBackoffBase = 30 * time.Second
+	maxLoginBackoff  = time.Hour
+)
+
+// loginBackoff is how long to wait after the last failure, doubling with
+// every failure past the free ones.
+func loginBackoff(failures, free int64) time.Duration {
+	if failures < free {
+		return 0
+	}
+
+	delay := loginBackoffBase
+	for i := free; i < failures && delay < maxLoginBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxLoginBackoff)
+}
+
+// checkLoginLock fails with AccountLockedError while the email or the IP
+// address are backing off. The IP address is skipped when empty, as a
+// proxy's address would lock out everyone behind it. It must run after
+// LockLoginEmail and LockLoginIP, in the transaction recording the attempt.
+func checkLoginLock(ctx context.Context, queries *pgstore.Queries, email, ipAddress string) error {
+	byEmail, err := queries.GetEmailLoginFailures(ctx, email)
+	if err != nil {
+		return err
+	}
+	until := byEmail.LastFailure.Add(loginBackoff(byEmail.Failures, freeEmailFailures))
+
+	if ipAddress != "" {
+		byIP, err := queries.GetIPLoginFailures(ctx, ipAddress)
+		if err != nil {
+			return err
+		}
+		if ipUntil := byIP.LastFailure.Add(loginBackoff(byIP.Failures, freeIPFailures)); ipUntil.After(until) {
+			until = ipUntil
+		}
+	}
+
+	if wait := time.Until(until); wait > 0 {
+		return AccountLockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// recordLoginAttempt keeps every attempt, both for backing off and for audit.
+func recordLoginAttempt(
+	ctx context.Context,
+	queries *pgstore.Queries,
+	email, ipAddress string,
+	userId uuid.UUID,
+	outcome string,
+) {
+	err := queries.RecordLoginAttempt(ctx, pgstore.RecordLoginAttemptParams{
+		Email:     email,
+		UserID:    pgtype.UUID{Bytes: userId, Valid: userId != uuid.Nil},
+		IpAddress: ipAddress,
+		Outcome:   outcome,
+	})
+	if err != nil {
+		slog.Error("Failed to record login attempt", "email", email, "outcome", outcome, "error", err)
+	}
+}
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
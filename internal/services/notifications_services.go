@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/nathancamolez-dev/go-bid/internal/mailer"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+const (
+	OutbidNotification = "outbid"
+
+	dispatchInterval  = 5 * time.Second
+	dispatchBatchSize = 50
+	deliveryLease     = time.Minute
+	maxDeliveries     = 8
+	retryBaseDelay    = 30 * time.Second
+	maxRetryDelay     = time.Hour
+)
+
+type OutbidPayload struct {
+	ProductID   uuid.UUID      `json:"product_id"`
+	ProductName string         `json:"product_name"`
+	Amount      money.Decimal  `json:"amount"`
+	Currency    money.Currency `json:"currency"`
+}
+
+// Notification is what senders deliver, with the recipient already looked up.
+type Notification struct {
+	ID        uuid.UUID       `json:"id"`
+	Kind      string          `json:"kind"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+
+	Email    string `json:"-"`
+	UserName string `json:"-"`
+}
+
+// NotificationSender is a delivery channel for notifications. Channel names
+// it in the outbox, so it must be unique and stay the same across releases.
+type NotificationSender interface {
+	Channel() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// NotificationDispatcher delivers the notifications written to the outbox.
+// Rows are claimed with SKIP LOCKED, so several instances can run it at once.
+type NotificationDispatcher struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+	senders []NotificationSender
+}
+
+func NewNotificationDispatcher(pool *pgxpool.Pool, senders ...NotificationSender) NotificationDispatcher {
+	return NotificationDispatcher{
+		pool:    pool,
+		queries: pgstore.New(pool),
+		senders: senders,
+	}
+}
+
+func (d NotificationDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.dispatch(ctx); err != nil {
+			slog.Error("Failed to dispatch notifications", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d NotificationDispatcher) dispatch(ctx context.Context) error {
+	claimed, err := d.queries.ClaimPendingNotifications(ctx, pgstore.ClaimPendingNotificationsParams{
+		Lease:     pgtype.Interval{Microseconds: deliveryLease.Microseconds(), Valid: true},
+		BatchSize: dispatchBatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, row := range claimed {
+		err := d.deliver(ctx, row)
+		if err == nil {
+			err = d.queries.MarkNotificationDelivered(ctx, row.ID)
+			if err != nil {
+				slog.Error("Failed to mark notification delivered", "id", row.ID, "error", err)
+			}
+			continue
+		}
+
+		slog.Error("Failed to deliver notification", "id", row.ID, "attempt", row.Attempts, "error", err)
+
+		status := "pending"
+		if row.Attempts >= maxDeliveries {
+			status = "failed"
+		}
+		err = d.queries.MarkNotificationFailed(ctx, pgstore.MarkNotificationFailedParams{
+			ID:            row.ID,
+			Status:        status,
+			LastError:     err.Error(),
+			NextAttemptAt: time.Now().Add(retryDelay(row.Attempts)),
+		})
+		if err != nil {
+			slog.Error("Failed to record notification failure", "id", row.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (d NotificationDispatcher) deliver(ctx context.Context, row pgstore.Notification) error {
+	user, err := d.queries.GetUserById(ctx, row.UserID)
+	if err != nil {
+		return err
+	}
+
+	n := Notification{
+		ID:        row.ID,
+		Kind:      row.Kind,
+		UserID:    row.UserID,
+		Payload:   row.Payload,
+		CreatedAt: row.CreatedAt,
+		Email:     user.Email,
+		UserName:  user.UserName,
+	}
+
+	// Each channel is marked as soon as it succeeds, so a retry only goes
+	// through the ones that failed.
+	var errs []error
+	for _, sender := range d.senders {
+		channel := sender.Channel()
+		if slices.Contains(row.DeliveredChannels, channel) {
+			continue
+		}
+		if err := sender.Send(ctx, n); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+			continue
+		}
+		err := d.queries.MarkNotificationChannelDelivered(ctx, pgstore.MarkNotificationChannelDeliveredParams{
+			ID:      row.ID,
+			Channel: channel,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func retryDelay(attempts int32) time.Duration {
+	delay := retryBaseDelay
+	for i := int32(1); i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// EmailNotificationSender emails notifications to the user.
+type EmailNotificationSender struct {
+	Mailer mailer.Mailer
+}
+
+func (s EmailNotificationSender) Channel() string {
+	return "email"
+}
+
+func (s EmailNotificationSender) Send(ctx context.Context, n Notification) error {
+	switch n.Kind {
+	case OutbidNotification:
+		var payload OutbidPayload
+		if err := json.Unmarshal(n.Payload, &payload); err != nil {
+			return err
+		}
+		return s.Mailer.Send(ctx, mailer.Mail{
+			To:      n.Email,
+			Subject: fmt.Sprintf("You have been outbid on %s", payload.ProductName),
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nSomeone placed a bid of %s %s on %s, above yours.\n",
+				n.UserName, payload.Amount, payload.Currency, payload.ProductName,
+			),
+		})
+	default:
+		return fmt.Errorf("no email template for %q notifications", n.Kind)
+	}
+}
+
+// WebhookNotificationSender posts notifications as JSON to URL, signed with an
+// HMAC-SHA256 of the body in the X-Gobid-Signature header. Receivers should
+// dedupe on the notification id, since failed deliveries are retried.
+type WebhookNotificationSender struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func (s WebhookNotificationSender) Channel() string {
+	return "webhook"
+}
+
+func (s WebhookNotificationSender) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gobid-Notification-Id", n.ID.String())
+
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write(body)
+	req.Header.Set("X-Gobid-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
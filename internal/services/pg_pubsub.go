@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	auctionEventsChannel = "gobid_auction_events"
+	reconnectDelay       = 2 * time.Second
+)
+
+// PgPubSub fans room events out through Postgres LISTEN/NOTIFY.
+type PgPubSub struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgPubSub(pool *pgxpool.Pool) *PgPubSub {
+	return &PgPubSub{pool: pool}
+}
+
+func (ps *PgPubSub) Publish(ctx context.Context, event RoomEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = ps.pool.Exec(ctx, "SELECT pg_notify($1, $2)", auctionEventsChannel, string(payload))
+	return err
+}
+
+func (ps *PgPubSub) Subscribe(ctx context.Context) (<-chan RoomEvent, error) {
+	conn, err := ps.listen(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan RoomEvent, eventsBufferSize)
+	go func() {
+		defer close(events)
+		for {
+			err := ps.forward(ctx, conn, events)
+			conn.Close(context.Background())
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("Lost auction events listener, reconnecting", "error", err)
+
+			for conn == nil || conn.IsClosed() {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(reconnectDelay):
+				}
+				conn, err = ps.listen(ctx)
+				if err != nil {
+					slog.Error("Failed to reconnect auction events listener", "error", err)
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// listen takes a connection out of the pool, since it stays busy waiting for
+// notifications for as long as the subscription lives.
+func (ps *PgPubSub) listen(ctx context.Context) (*pgx.Conn, error) {
+	pooled, err := ps.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn := pooled.Hijack()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+auctionEventsChannel); err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (ps *PgPubSub) forward(ctx context.Context, conn *pgx.Conn, events chan<- RoomEvent) error {
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event RoomEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			slog.Error("Invalid auction event payload", "error", err)
+			continue
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// PgRoomLocker hands out room ownership through session level advisory
+// locks, held on a dedicated connection so they are released by Postgres if
+// this instance goes away.
+type PgRoomLocker struct {
+	mu   sync.Mutex
+	pool *pgxpool.Pool
+	conn *pgx.Conn
+	held map[uuid.UUID]struct{}
+}
+
+func NewPgRoomLocker(pool *pgxpool.Pool) *PgRoomLocker {
+	return &PgRoomLocker{
+		pool: pool,
+		held: make(map[uuid.UUID]struct{}),
+	}
+}
+
+func (l *PgRoomLocker) TryAcquire(ctx context.Context, roomId uuid.UUID) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.connect(ctx); err != nil {
+		return false, err
+	}
+
+	if _, ok := l.held[roomId]; ok {
+		if err := l.conn.Ping(ctx); err != nil {
+			l.reset()
+			return false, err
+		}
+		return true, nil
+	}
+
+	var acquired bool
+	err := l.conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", advisoryKey(roomId)).
+		Scan(&acquired)
+	if err != nil {
+		l.reset()
+		return false, err
+	}
+
+	if acquired {
+		l.held[roomId] = struct{}{}
+	}
+	return acquired, nil
+}
+
+func (l *PgRoomLocker) Release(ctx context.Context, roomId uuid.UUID) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.held[roomId]; !ok {
+		return nil
+	}
+	delete(l.held, roomId)
+
+	_, err := l.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", advisoryKey(roomId))
+	if err != nil {
+		l.reset()
+	}
+	return err
+}
+
+func (l *PgRoomLocker) connect(ctx context.Context) error {
+	if l.conn != nil && !l.conn.IsClosed() {
+		return nil
+	}
+
+	l.reset()
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	l.conn = pooled.Hijack()
+	return nil
+}
+
+// reset drops the connection, and with it every lock it was holding.
+func (l *PgRoomLocker) reset() {
+	if l.conn != nil {
+		l.conn.Close(context.Background())
+		l.conn = nil
+	}
+	clear(l.held)
+}
+
+func advisoryKey(id uuid.UUID) int64 {
+	return int64(binary.BigEndian.Uint64(id[:8]))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/nathancamolez-dev/go-bid/internal/money"
 	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
 )
 
@@ -28,14 +29,20 @@ func (ps ProductService) CreateProduct(
 	ctx context.Context,
 	sellerId uuid.UUID,
 	productName, description string,
-	baseprice float64,
+	baseprice money.Money,
+	currency money.Currency,
 	auctionEnd time.Time,
 ) (uuid.UUID, error) {
+	if err := ensureVerified(ctx, ps.queries, sellerId); err != nil {
+		return uuid.UUID{}, err
+	}
+
 	id, err := ps.queries.CreateProduct(ctx, pgstore.CreateProductParams{
 		SellerID:    sellerId,
 		ProductName: productName,
 		Description: description,
 		Baseprice:   baseprice,
+		Currency:    currency,
 		AuctionEnd:  auctionEnd,
 	})
 	if err != nil {
@@ -61,3 +68,57 @@ func (ps ProductService) GetProductById(
 	return product, nil
 
 }
+
+// AuctionSnapshot is the state of an auction sent to clients as they join
+// its room.
+type AuctionSnapshot struct {
+	ProductID    uuid.UUID      `json:"product_id"`
+	ProductName  string         `json:"product_name"`
+	CurrentPrice money.Decimal  `json:"current_price"`
+	Currency     money.Currency `json:"currency"`
+	AuctionEnd   time.Time      `json:"auction_end"`
+	Seller       SellerSummary  `json:"seller"`
+}
+
+type SellerSummary struct {
+	ID         uuid.UUID  `json:"id"`
+	UserName   string     `json:"user_name"`
+	Reputation Reputation `json:"reputation"`
+}
+
+// GetAuctionSnapshot reads the current price of the product, the highest
+// bid or the base price, and who sells it.
+func (ps ProductService) GetAuctionSnapshot(ctx context.Context, product pgstore.Product) (AuctionSnapshot, error) {
+	price := product.Baseprice
+	bid, err := ps.queries.GetHighestBidByProductId(ctx, product.ID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return AuctionSnapshot{}, err
+		}
+	} else {
+		price = bid.BidAmount
+	}
+
+	seller, err := ps.queries.GetUserById(ctx, product.SellerID)
+	if err != nil {
+		return AuctionSnapshot{}, err
+	}
+
+	reputation, err := getReputation(ctx, ps.queries, product.SellerID)
+	if err != nil {
+		return AuctionSnapshot{}, err
+	}
+
+	return AuctionSnapshot{
+		ProductID:    product.ID,
+		ProductName:  product.ProductName,
+		CurrentPrice: price.Decimal(product.Currency),
+		Currency:     product.Currency,
+		AuctionEnd:   product.AuctionEnd,
+		Seller: SellerSummary{
+			ID:         seller.ID,
+			UserName:   seller.UserName,
+			Reputation: reputation,
+		},
+	}, nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const eventsBufferSize = 256
+
+// RoomEvent is a message addressed to every copy of an auction room across
+// the cluster. When Target is set only that user receives the message,
+// otherwise every connected client except Exclude does. Origin is the
+// instance that published it, which already handled the event locally.
+type RoomEvent struct {
+	Origin  uuid.UUID `json:"origin"`
+	RoomID  uuid.UUID `json:"room_id"`
+	Target  uuid.UUID `json:"target"`
+	Exclude uuid.UUID `json:"exclude"`
+	Message Message   `json:"message"`
+}
+
+// PubSub fans room events out to every api instance.
+type PubSub interface {
+	Publish(ctx context.Context, event RoomEvent) error
+	Subscribe(ctx context.Context) (<-chan RoomEvent, error)
+}
+
+// RoomLocker decides which instance owns a room. Only the owner writes bids
+// for it, so bids for a product are always processed in a single place.
+type RoomLocker interface {
+	TryAcquire(ctx context.Context, roomId uuid.UUID) (bool, error)
+	Release(ctx context.Context, roomId uuid.UUID) error
+}
+
+// LocalPubSub delivers events in process, for single instance deployments.
+type LocalPubSub struct {
+	mu          sync.Mutex
+	subscribers map[chan RoomEvent]struct{}
+}
+
+func NewLocalPubSub() *LocalPubSub {
+	return &LocalPubSub{
+		subscribers: make(map[chan RoomEvent]struct{}),
+	}
+}
+
+func (ps *LocalPubSub) Publish(ctx context.Context, event RoomEvent) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for events := range ps.subscribers {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (ps *LocalPubSub) Subscribe(ctx context.Context) (<-chan RoomEvent, error) {
+	events := make(chan RoomEvent, eventsBufferSize)
+
+	ps.mu.Lock()
+	ps.subscribers[events] = struct{}{}
+	ps.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		ps.mu.Lock()
+		delete(ps.subscribers, events)
+		ps.mu.Unlock()
+		close(events)
+	}()
+
+	return events, nil
+}
+
+// LocalRoomLocker grants every room to the current instance.
+type LocalRoomLocker struct{}
+
+func (LocalRoomLocker) TryAcquire(ctx context.Context, roomId uuid.UUID) (bool, error) {
+	return true, nil
+}
+
+func (LocalRoomLocker) Release(ctx context.Context, roomId uuid.UUID) error {
+	return nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// lastSeenResolution limits how often last_seen_at is written for a session.
+const lastSeenResolution = time.Minute
+
+// SessionService indexes the scs sessions by user, so users can list and
+// revoke them. Sessions missing from the index are considered revoked.
+type SessionService struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewSessionService(pool *pgxpool.Pool) SessionService {
+	return SessionService{
+		pool:    pool,
+		queries: pgstore.New(pool),
+	}
+}
+
+type UserSession struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// IndexSession records that the session with this token belongs to the user.
+func (ss SessionService) IndexSession(
+	ctx context.Context,
+	token string,
+	userId uuid.UUID,
+	userAgent, ipAddress string,
+) error {
+	if err := ss.queries.PruneUserSessions(ctx, userId); err != nil {
+		return err
+	}
+
+	return ss.queries.CreateUserSession(ctx, pgstore.CreateUserSessionParams{
+		Token:     token,
+		UserID:    userId,
+		UserAgent: userAgent,
+		IpAddress: ipAddress,
+	})
+}
+
+// CheckSession reports whether the session is still indexed for the user,
+// and keeps its last_seen_at up to date.
+func (ss SessionService) CheckSession(ctx context.Context, token string, userId uuid.UUID) (bool, error) {
+	session, err := ss.queries.GetUserSessionByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	if session.UserID != userId {
+		return false, nil
+	}
+
+	if time.Since(session.LastSeenAt) > lastSeenResolution {
+		if err := ss.queries.TouchUserSession(ctx, session.ID); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (ss SessionService) ListSessions(
+	ctx context.Context,
+	userId uuid.UUID,
+	currentToken string,
+) ([]UserSession, error) {
+	rows, err := ss.queries.ListUserSessions(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]UserSession, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, UserSession{
+			ID:         row.ID,
+			UserAgent:  row.UserAgent,
+			IPAddress:  row.IpAddress,
+			CreatedAt:  row.CreatedAt,
+			LastSeenAt: row.LastSeenAt,
+			Current:    row.Token == currentToken,
+		})
+	}
+	return sessions, nil
+}
+
+// RevokeSession logs one of the user's sessions out.
+func (ss SessionService) RevokeSession(ctx context.Context, userId, sessionId uuid.UUID) error {
+	tx, err := ss.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := ss.queries.WithTx(tx)
+
+	token, err := queries.DeleteUserSession(ctx, pgstore.DeleteUserSessionParams{
+		ID:     sessionId,
+		UserID: userId,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	if err := queries.DeleteSessions(ctx, []string{token}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ForgetSession drops the index entry of a session scs already destroyed.
+func (ss SessionService) ForgetSession(ctx context.Context, token string) error {
+	return ss.queries.DeleteUserSessionByToken(ctx, token)
+}
+
+// revokeOtherSessions logs the user out of every session but keepToken,
+// which may be empty to log them out everywhere. Refresh tokens are always
+// revoked.
+func revokeOtherSessions(
+	ctx context.Context,
+	queries *pgstore.Queries,
+	userId uuid.UUID,
+	keepToken string,
+) error {
+	if err := queries.RevokeUserRefreshTokens(ctx, userId); err != nil {
+		return err
+	}
+
+	tokens, err := queries.DeleteOtherUserSessions(ctx, pgstore.DeleteOtherUserSessionsParams{
+		UserID:    userId,
+		KeepToken: keepToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(tokens) == 0 {
+		return nil
+	}
+	return queries.DeleteSessions(ctx, tokens)
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// Purposes of the single use tokens in user_tokens.
+const (
+	EmailVerificationToken = "email_verification"
+	PasswordResetToken     = "password_reset"
+)
+
+// newToken returns a random token for the user and the hash that is stored
+// in its place.
+func newToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
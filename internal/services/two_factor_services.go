@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+	"github.com/nathancamolez-dev/go-bid/internal/totp"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two factor authentication is not enabled")
+	ErrTwoFactorNotPending     = errors.New("two factor authentication setup was not started")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two factor code")
+)
+
+const (
+	twoFactorIssuer   = "GoBid"
+	recoveryCodeCount = 10
+)
+
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// SetupTwoFactor starts TOTP enrollment. The secret only protects the
+// account once ConfirmTwoFactor sees a code generated from it.
+func (us *UserServices) SetupTwoFactor(ctx context.Context, id uuid.UUID) (TwoFactorSetup, error) {
+	user, err := us.GetUserById(ctx, id)
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+	if user.TotpEnabledAt.Valid {
+		return TwoFactorSetup{}, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+
+	err = us.queries.SetPendingTotpSecret(ctx, pgstore.SetPendingTotpSecretParams{
+		ID:                id,
+		TotpPendingSecret: pgtype.Text{String: secret, Valid: true},
+	})
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+
+	return TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(twoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables TOTP once the user proves their app is set up, and
+// returns recovery codes. They are only stored hashed, so this is the only
+// time they can be shown.
+func (us *UserServices) ConfirmTwoFactor(ctx context.Context, id uuid.UUID, code string) ([]string, error) {
+	user, err := us.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.TotpEnabledAt.Valid {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if !user.TotpPendingSecret.Valid {
+		return nil, ErrTwoFactorNotPending
+	}
+
+	step, ok := totp.Match(user.TotpPendingSecret.String, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	err = queries.EnableTotp(ctx, pgstore.EnableTotpParams{ID: id, LastStep: step})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := replaceRecoveryCodes(ctx, queries, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns TOTP off for a user who knows their password.
+func (us *UserServices) DisableTwoFactor(ctx context.Context, id uuid.UUID, password string) error {
+	user, err := us.GetUserById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.TotpEnabledAt.Valid {
+		return ErrTwoFactorNotEnabled
+	}
+
+	err = bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	if err := queries.DisableTotp(ctx, id); err != nil {
+		return err
+	}
+	if err := queries.DeleteRecoveryCodes(ctx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (us *UserServices) TwoFactorEnabled(ctx context.Context, id uuid.UUID) (bool, error) {
+	user, err := us.GetUserById(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return user.TotpEnabledAt.Valid, nil
+}
+
+// VerifyTwoFactor accepts a TOTP code, each at most once, or an unused
+// recovery code.
+func (us *UserServices) VerifyTwoFactor(ctx context.Context, id uuid.UUID, code string) error {
+	user, err := us.GetUserById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.TotpEnabledAt.Valid {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Match(user.TotpSecret.String, code, time.Now()); ok {
+		used, err := us.queries.UseTotpStep(ctx, pgstore.UseTotpStepParams{ID: id, Step: step})
+		if err != nil {
+			return err
+		}
+		if used == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := us.queries.UseRecoveryCode(ctx, pgstore.UseRecoveryCodeParams{
+		UserID:   id,
+		CodeHash: hashToken(normalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, queries *pgstore.Queries, id uuid.UUID) ([]string, error) {
+	if err := queries.DeleteRecoveryCodes(ctx, id); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+
+		err := queries.CreateRecoveryCode(ctx, pgstore.CreateRecoveryCodeParams{
+			UserID:   id,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -3,13 +3,19 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/nathancamolez-dev/go-bid/internal/mailer"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
 	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
 )
 
@@ -17,15 +23,67 @@ var ErrDuplicatedEmailOrUsername = errors.New("duplicated email or username")
 
 var ErrInvalidCredentials = errors.New("invalid credentials")
 
+var ErrUserNotFound = errors.New("user not found")
+
+var ErrEmailNotVerified = errors.New("email not verified")
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+var ErrAccountSuspended = errors.New("account suspended")
+
+const (
+	passwordCost         = 12
+	emailVerificationTTL = 24 * time.Hour
+	passwordResetTTL     = time.Hour
+)
+
+// Profile is what users see of their own account.
+type Profile struct {
+	ID              uuid.UUID       `json:"id"`
+	UserName        string          `json:"user_name"`
+	Email           string          `json:"email"`
+	Bio             string          `json:"bio"`
+	DisplayCurrency *money.Currency `json:"display_currency"`
+	CreatedAt       time.Time       `json:"created_at"`
+
+	// Only filled by GetProfile.
+	Reputation *Reputation `json:"reputation,omitempty"`
+}
+
+// PublicProfile is what anyone can see of a user. It never carries the
+// email or the password hash.
+type PublicProfile struct {
+	ID             uuid.UUID  `json:"id"`
+	UserName       string     `json:"user_name"`
+	Bio            string     `json:"bio"`
+	MemberSince    time.Time  `json:"member_since"`
+	ActiveListings int64      `json:"active_listings"`
+	CompletedSales int64      `json:"completed_sales"`
+	Reputation     Reputation `json:"reputation"`
+}
+
+func NewProfile(user pgstore.User) Profile {
+	return Profile{
+		ID:              user.ID,
+		UserName:        user.UserName,
+		Email:           user.Email,
+		Bio:             user.Bio,
+		DisplayCurrency: user.DisplayCurrency,
+		CreatedAt:       user.CreatedAt,
+	}
+}
+
 type UserServices struct {
 	pool    *pgxpool.Pool
 	queries *pgstore.Queries
+	mailer  mailer.Mailer
 }
 
-func NewUserService(pool *pgxpool.Pool) UserServices {
+func NewUserService(pool *pgxpool.Pool, mailer mailer.Mailer) UserServices {
 	return UserServices{
 		pool:    pool,
 		queries: pgstore.New(pool),
+		mailer:  mailer,
 	}
 }
 
@@ -36,7 +94,7 @@ func (us *UserServices) CreateUser(
 	password,
 	bio string,
 ) (uuid.UUID, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
@@ -46,7 +104,16 @@ func (us *UserServices) CreateUser(
 		PasswordHash: hash,
 		Bio:          bio,
 	}
-	id, err := us.queries.CreateUser(ctx, args)
+
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	id, err := queries.CreateUser(ctx, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation{
@@ -54,26 +121,555 @@ func (us *UserServices) CreateUser(
 		}
 		return uuid.UUID{}, err
 	}
+
+	token, err := createVerificationToken(ctx, queries, id)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	us.sendVerificationEmail(id, userName, email, token)
 	return id, nil
 }
 
-func (us *UserServices) AuthenticateUser(
-	ctx context.Context,
-	email, password string) (uuid.UUID, error) {
+// ResendVerification emails a new verification token to the user with this
+// email, making the previous ones unusable. Like ForgotPassword, it reports
+// no error for unknown or already verified emails.
+func (us *UserServices) ResendVerification(ctx context.Context, email string) error {
 	user, err := us.queries.GetUserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return uuid.UUID{}, ErrInvalidCredentials
+			return nil
 		}
+		return err
+	}
+	if user.EmailVerifiedAt.Valid {
+		return nil
+	}
+
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	err = queries.ExpireUserTokens(ctx, pgstore.ExpireUserTokensParams{
+		UserID:  user.ID,
+		Purpose: EmailVerificationToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	token, err := createVerificationToken(ctx, queries, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	us.sendVerificationEmail(user.ID, user.UserName, user.Email, token)
+	return nil
+}
+
+func createVerificationToken(ctx context.Context, queries *pgstore.Queries, userId uuid.UUID) (string, error) {
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	err = queries.CreateUserToken(ctx, pgstore.CreateUserTokenParams{
+		UserID:    userId,
+		Purpose:   EmailVerificationToken,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendVerificationEmail sends the token in the background, as ForgotPassword
+// does, so a slow or failing mail server doesn't hold up the request. A lost
+// email can be sent again with ResendVerification.
+func (us *UserServices) sendVerificationEmail(userId uuid.UUID, userName, email, token string) {
+	go func() {
+		err := us.mailer.Send(context.Background(), mailer.Mail{
+			To:      email,
+			Subject: "Verify your email",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nUse this token to verify your email, it expires in 24 hours:\n\n%s\n",
+				userName, token,
+			),
+		})
+		if err != nil {
+			slog.Error("Failed to send verification email", "user_id", userId, "error", err)
+		}
+	}()
+}
+
+// VerifyEmail consumes a verification token and marks the email of its user
+// as verified.
+func (us *UserServices) VerifyEmail(ctx context.Context, token string) (uuid.UUID, error) {
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
 		return uuid.UUID{}, err
 	}
-	err = bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password))
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	id, err := queries.ConsumeUserToken(ctx, pgstore.ConsumeUserTokenParams{
+		TokenHash: hashToken(token),
+		Purpose:   EmailVerificationToken,
+	})
 	if err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return uuid.UUID{}, ErrInvalidCredentials
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.UUID{}, ErrInvalidToken
+		}
+		return uuid.UUID{}, err
+	}
+
+	if err := queries.MarkEmailVerified(ctx, id); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.UUID{}, err
+	}
+	return id, nil
+}
+
+// ensureVerified fails with ErrEmailNotVerified for users who have not
+// verified their email yet.
+func ensureVerified(ctx context.Context, queries *pgstore.Queries, id uuid.UUID) error {
+	user, err := queries.GetUserById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if !user.EmailVerifiedAt.Valid {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// SuspendedError is returned, matching ErrAccountSuspended, for suspended
+// users. Until is nil for bans.
+type SuspendedError struct {
+	Reason string
+	Until  *time.Time
+}
+
+func (e SuspendedError) Error() string {
+	return ErrAccountSuspended.Error()
+}
+
+func (e SuspendedError) Is(target error) bool {
+	return target == ErrAccountSuspended
+}
+
+// suspension returns the SuspendedError of a suspended user, or nil when
+// the user isn't suspended or the suspension is over.
+func suspension(user pgstore.User) error {
+	if !user.SuspendedAt.Valid {
+		return nil
+	}
+	if !user.SuspendedUntil.Valid {
+		return SuspendedError{Reason: user.SuspensionReason}
+	}
+	if !user.SuspendedUntil.Time.After(time.Now()) {
+		return nil
+	}
+	until := user.SuspendedUntil.Time
+	return SuspendedError{Reason: user.SuspensionReason, Until: &until}
+}
+
+// ensureActive fails with SuspendedError for suspended users, and with
+// PendingDeletionError for accounts scheduled for deletion.
+func ensureActive(ctx context.Context, queries *pgstore.Queries, id uuid.UUID) error {
+	user, err := queries.GetUserById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
 		}
+		return err
+	}
+	if err := suspension(user); err != nil {
+		return err
+	}
+	return pendingDeletion(user)
+}
+
+// CheckActive fails with SuspendedError for suspended users, and with
+// PendingDeletionError for accounts scheduled for deletion.
+func (us *UserServices) CheckActive(ctx context.Context, id uuid.UUID) error {
+	return ensureActive(ctx, us.queries, id)
+}
+
+// AuthenticateUser checks the credentials, refusing with ErrAccountLocked
+// while too many attempts failed recently for the email or the IP address.
+// Accounts scheduled for deletion have to be restored with RestoreAccount.
+func (us *UserServices) AuthenticateUser(
+	ctx context.Context,
+	email, password, ipAddress string) (uuid.UUID, error) {
+	user, err := us.checkCredentials(ctx, email, password, ipAddress)
+	if err != nil {
 		return uuid.UUID{}, err
 	}
+
+	key := normalizeLoginEmail(email)
+	if err := suspension(user); err != nil {
+		recordLoginAttempt(ctx, us.queries, key, ipAddress, user.ID, loginOutcomeLocked)
+		return uuid.UUID{}, err
+	}
+	if err := pendingDeletion(user); err != nil {
+		recordLoginAttempt(ctx, us.queries, key, ipAddress, user.ID, loginOutcomeLocked)
+		return uuid.UUID{}, err
+	}
+
+	recordLoginAttempt(ctx, us.queries, key, ipAddress, user.ID, loginOutcomeSuccess)
 	return user.ID, nil
 
 }
+
+// checkCredentials returns the user with this email and password, recording
+// failed attempts. Attempts for the same email or from the same IP address
+// wait for each other, so concurrent ones can't all get past the lockout
+// before any failure is recorded. ipAddress is empty when the client's
+// address is unknown, and only the email backs off then.
+func (us *UserServices) checkCredentials(
+	ctx context.Context,
+	email, password, ipAddress string) (pgstore.User, error) {
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
+		return pgstore.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	key := normalizeLoginEmail(email)
+	if err := queries.LockLoginEmail(ctx, key); err != nil {
+		return pgstore.User{}, err
+	}
+	if ipAddress != "" {
+		if err := queries.LockLoginIP(ctx, ipAddress); err != nil {
+			return pgstore.User{}, err
+		}
+	}
+
+	user, err := checkCredentialsLocked(ctx, queries, key, email, password, ipAddress)
+
+	// The failures recorded above are kept whatever the outcome.
+	if commitErr := tx.Commit(ctx); commitErr != nil {
+		return pgstore.User{}, commitErr
+	}
+	return user, err
+}
+
+func checkCredentialsLocked(
+	ctx context.Context,
+	queries *pgstore.Queries,
+	key, email, password, ipAddress string) (pgstore.User, error) {
+	if err := checkLoginLock(ctx, queries, key, ipAddress); err != nil {
+		if errors.Is(err, ErrAccountLocked) {
+			recordLoginAttempt(ctx, queries, key, ipAddress, uuid.Nil, loginOutcomeLocked)
+		}
+		return pgstore.User{}, err
+	}
+
+	user, err := queries.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			recordLoginAttempt(ctx, queries, key, ipAddress, uuid.Nil, loginOutcomeFailure)
+			return pgstore.User{}, ErrInvalidCredentials
+		}
+		return pgstore.User{}, err
+	}
+	err = bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			recordLoginAttempt(ctx, queries, key, ipAddress, user.ID, loginOutcomeFailure)
+			return pgstore.User{}, ErrInvalidCredentials
+		}
+		return pgstore.User{}, err
+	}
+	return user, nil
+}
+
+func (us *UserServices) GetUserById(ctx context.Context, id uuid.UUID) (pgstore.User, error) {
+	user, err := us.queries.GetUserById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgstore.User{}, ErrUserNotFound
+		}
+		return pgstore.User{}, err
+	}
+	return user, nil
+}
+
+// SetDisplayCurrency changes the currency amounts are converted to for the
+// user, an empty currency turns the conversion off.
+func (us *UserServices) SetDisplayCurrency(
+	ctx context.Context,
+	id uuid.UUID,
+	currency money.Currency,
+) error {
+	var displayCurrency *money.Currency
+	if currency != "" {
+		displayCurrency = &currency
+	}
+
+	return us.queries.UpdateUserDisplayCurrency(ctx, pgstore.UpdateUserDisplayCurrencyParams{
+		ID:              id,
+		DisplayCurrency: displayCurrency,
+	})
+}
+
+// UpdateProfile changes the fields that are not nil.
+func (us *UserServices) UpdateProfile(
+	ctx context.Context,
+	id uuid.UUID,
+	userName, bio *string,
+) (pgstore.User, error) {
+	args := pgstore.UpdateUserProfileParams{ID: id}
+	if userName != nil {
+		args.UserName = pgtype.Text{String: *userName, Valid: true}
+	}
+	if bio != nil {
+		args.Bio = pgtype.Text{String: *bio, Valid: true}
+	}
+
+	user, err := us.queries.UpdateUserProfile(ctx, args)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgstore.User{}, ErrUserNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			return pgstore.User{}, ErrDuplicatedEmailOrUsername
+		}
+		return pgstore.User{}, err
+	}
+	return user, nil
+}
+
+// GetProfile returns the user's own profile along with their reputation.
+func (us *UserServices) GetProfile(ctx context.Context, id uuid.UUID) (Profile, error) {
+	user, err := us.GetUserById(ctx, id)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	reputation, err := getReputation(ctx, us.queries, id)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	profile := NewProfile(user)
+	profile.Reputation = &reputation
+	return profile, nil
+}
+
+func (us *UserServices) GetPublicProfile(ctx context.Context, id uuid.UUID) (PublicProfile, error) {
+	user, err := us.GetUserById(ctx, id)
+	if err != nil {
+		return PublicProfile{}, err
+	}
+	if user.DeletionScheduledFor.Valid {
+		return PublicProfile{}, ErrUserNotFound
+	}
+
+	stats, err := us.queries.GetSellerStats(ctx, id)
+	if err != nil {
+		return PublicProfile{}, err
+	}
+
+	reputation, err := getReputation(ctx, us.queries, id)
+	if err != nil {
+		return PublicProfile{}, err
+	}
+
+	return PublicProfile{
+		ID:             user.ID,
+		UserName:       user.UserName,
+		Bio:            user.Bio,
+		MemberSince:    user.CreatedAt,
+		ActiveListings: stats.ActiveListings,
+		CompletedSales: stats.CompletedSales,
+		Reputation:     reputation,
+	}, nil
+}
+
+// ForgotPassword emails a password reset token if a user has this email. It
+// reports no error for unknown emails, and sends the email in the background,
+// so callers cannot tell whether the account exists.
+func (us *UserServices) ForgotPassword(ctx context.Context, email string) error {
+	user, err := us.queries.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	// Only the latest reset email works.
+	err = queries.ExpireUserTokens(ctx, pgstore.ExpireUserTokensParams{
+		UserID:  user.ID,
+		Purpose: PasswordResetToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return err
+	}
+	err = queries.CreateUserToken(ctx, pgstore.CreateUserTokenParams{
+		UserID:    user.ID,
+		Purpose:   PasswordResetToken,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		err := us.mailer.Send(context.Background(), mailer.Mail{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nUse this token to reset your password, it expires in 1 hour:\n\n%s\n\n"+
+					"If you did not ask for it, you can ignore this email.\n",
+				user.UserName, token,
+			),
+		})
+		if err != nil {
+			slog.Error("Failed to send password reset email", "user_id", user.ID, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// ResetPassword consumes a password reset token, sets the new password and
+// logs the user out everywhere.
+func (us *UserServices) ResetPassword(ctx context.Context, token, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return err
+	}
+
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	id, err := queries.ConsumeUserToken(ctx, pgstore.ConsumeUserTokenParams{
+		TokenHash: hashToken(token),
+		Purpose:   PasswordResetToken,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	err = queries.UpdateUserPassword(ctx, pgstore.UpdateUserPasswordParams{
+		ID:           id,
+		PasswordHash: hash,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := revokeOtherSessions(ctx, queries, id, ""); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ChangePassword replaces the password of a user who knows the current one,
+// and logs them out of every session but keepToken.
+func (us *UserServices) ChangePassword(
+	ctx context.Context,
+	id uuid.UUID,
+	currentPassword, newPassword, keepToken string,
+) error {
+	user, err := us.GetUserById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(currentPassword))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), passwordCost)
+	if err != nil {
+		return err
+	}
+
+	tx, err := us.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := us.queries.WithTx(tx)
+
+	err = queries.UpdateUserPassword(ctx, pgstore.UpdateUserPasswordParams{
+		ID:           id,
+		PasswordHash: hash,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = queries.ExpireUserTokens(ctx, pgstore.ExpireUserTokensParams{
+		UserID:  id,
+		Purpose: PasswordResetToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := revokeOtherSessions(ctx, queries, id, keepToken); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+type WatchlistService struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewWatchlistService(pool *pgxpool.Pool) WatchlistService {
+	return WatchlistService{
+		pool:    pool,
+		queries: pgstore.New(pool),
+	}
+}
+
+type WatchedProduct struct {
+	ProductID    uuid.UUID      `json:"product_id"`
+	ProductName  string         `json:"product_name"`
+	CurrentPrice money.Decimal  `json:"current_price"`
+	Currency     money.Currency `json:"currency"`
+	AuctionEnd   time.Time      `json:"auction_end"`
+	TimeLeft     int64          `json:"time_left_seconds"`
+	IsSold       bool           `json:"is_sold"`
+	IsHighBidder bool           `json:"is_high_bidder"`
+	WatchedAt    time.Time      `json:"watched_at"`
+}
+
+func (ws WatchlistService) WatchProduct(ctx context.Context, userId, productId uuid.UUID) error {
+	err := ws.queries.WatchProduct(ctx, pgstore.WatchProductParams{
+		UserID:    userId,
+		ProductID: productId,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+			return ErrProductNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (ws WatchlistService) UnwatchProduct(ctx context.Context, userId, productId uuid.UUID) error {
+	return ws.queries.UnwatchProduct(ctx, pgstore.UnwatchProductParams{
+		UserID:    userId,
+		ProductID: productId,
+	})
+}
+
+func (ws WatchlistService) ListWatchlist(ctx context.Context, userId uuid.UUID) ([]WatchedProduct, error) {
+	rows, err := ws.queries.ListWatchedProducts(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	watchlist := make([]WatchedProduct, 0, len(rows))
+	for _, row := range rows {
+		watchlist = append(watchlist, WatchedProduct{
+			ProductID:    row.ID,
+			ProductName:  row.ProductName,
+			CurrentPrice: money.Money(row.CurrentPrice).Decimal(row.Currency),
+			Currency:     row.Currency,
+			AuctionEnd:   row.AuctionEnd,
+			TimeLeft:     int64(max(row.AuctionEnd.Sub(now), 0).Seconds()),
+			IsSold:       row.IsSold,
+			IsHighBidder: row.IsHighBidder,
+			WatchedAt:    row.WatchedAt,
+		})
+	}
+	return watchlist, nil
+}
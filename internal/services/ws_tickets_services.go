@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/nathancamolez-dev/go-bid/internal/store/pgstore"
+)
+
+// WsTicketTTL is how long a websocket ticket can wait to be used.
+const WsTicketTTL = 30 * time.Second
+
+type WsTicketService struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewWsTicketService(pool *pgxpool.Pool) WsTicketService {
+	return WsTicketService{
+		pool:    pool,
+		queries: pgstore.New(pool),
+	}
+}
+
+// IssueWsTicket returns a ticket that authenticates a single websocket
+// connection of the user within WsTicketTTL.
+func (wts WsTicketService) IssueWsTicket(ctx context.Context, userId uuid.UUID) (string, error) {
+	// Tickets are short lived, so the expired ones are swept as new ones
+	// are issued.
+	if err := wts.queries.DeleteExpiredWsTickets(ctx); err != nil {
+		return "", err
+	}
+
+	ticket, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = wts.queries.CreateWsTicket(ctx, pgstore.CreateWsTicketParams{
+		UserID:     userId,
+		TicketHash: hash,
+		ExpiresAt:  time.Now().Add(WsTicketTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return ticket, nil
+}
+
+// ConsumeWsTicket resolves a ticket to its owner and invalidates it.
+func (wts WsTicketService) ConsumeWsTicket(ctx context.Context, ticket string) (uuid.UUID, error) {
+	userId, err := wts.queries.ConsumeWsTicket(ctx, hashToken(ticket))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.UUID{}, ErrInvalidToken
+		}
+		return uuid.UUID{}, err
+	}
+	return userId, nil
+}
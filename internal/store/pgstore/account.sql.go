@@ -0,0 +1,522 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: account.sql
+
+package pgstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+)
+
+const anonymizeUser = `-- name: AnonymizeUser :exec
+UPDATE users
+SET
+	user_name = $1,
+	email = $2,
+	password_hash = '',
+	bio = '',
+	display_currency = NULL,
+	email_verified_at = NULL,
+	totp_secret = NULL,
+	totp_pending_secret = NULL,
+	totp_enabled_at = NULL,
+	role = 'user',
+	suspension_reason = '',
+	anonymized_at = now(),
+	updated_at = now()
+WHERE id = $3
+`
+
+type AnonymizeUserParams struct {
+	UserName string    `json:"user_name"`
+	Email    string    `json:"email"`
+	ID       uuid.UUID `json:"id"`
+}
+
+func (q *Queries) AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) error {
+	_, err := q.db.Exec(ctx, anonymizeUser, arg.UserName, arg.Email, arg.ID)
+	return err
+}
+
+const cancelAccountDeletion = `-- name: CancelAccountDeletion :exec
+UPDATE users
+SET deletion_scheduled_for = NULL, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) CancelAccountDeletion(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, cancelAccountDeletion, id)
+	return err
+}
+
+const claimAccountsDueForDeletion = `-- name: ClaimAccountsDueForDeletion :many
+SELECT id, email FROM users
+WHERE deletion_scheduled_for <= now() AND anonymized_at IS NULL
+ORDER BY deletion_scheduled_for
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+type ClaimAccountsDueForDeletionRow struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+func (q *Queries) ClaimAccountsDueForDeletion(ctx context.Context, limit int32) ([]ClaimAccountsDueForDeletionRow, error) {
+	rows, err := q.db.Query(ctx, claimAccountsDueForDeletion, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimAccountsDueForDeletionRow
+	for rows.Next() {
+		var i ClaimAccountsDueForDeletionRow
+		if err := rows.Scan(&i.ID, &i.Email); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const clearFeedbackCommentsOfUser = `-- name: ClearFeedbackCommentsOfUser :exec
+UPDATE feedback SET comment = '' WHERE from_user_id = $1
+`
+
+func (q *Queries) ClearFeedbackCommentsOfUser(ctx context.Context, fromUserID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, clearFeedbackCommentsOfUser, fromUserID)
+	return err
+}
+
+const countActiveAuctionsOfUser = `-- name: CountActiveAuctionsOfUser :one
+SELECT COUNT(*) FROM products p
+WHERE p.auction_end > now()
+	AND (
+		p.seller_id = $1
+		OR $1 = (
+			SELECT b.user_id FROM bids b
+			WHERE b.product_id = p.id
+			ORDER BY b.bid_amount DESC
+			LIMIT 1
+		)
+	)
+`
+
+// Auctions the user sells or currently leads, which would be left without a
+// seller or a winner.
+func (q *Queries) CountActiveAuctionsOfUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countActiveAuctionsOfUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteApiTokensOfUser = `-- name: DeleteApiTokensOfUser :exec
+DELETE FROM api_tokens WHERE user_id = $1
+`
+
+func (q *Queries) DeleteApiTokensOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteApiTokensOfUser, userID)
+	return err
+}
+
+const deleteCreditLimitsOfUser = `-- name: DeleteCreditLimitsOfUser :exec
+DELETE FROM credit_limits WHERE user_id = $1
+`
+
+func (q *Queries) DeleteCreditLimitsOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteCreditLimitsOfUser, userID)
+	return err
+}
+
+const deleteLoginAttemptsOfUser = `-- name: DeleteLoginAttemptsOfUser :exec
+DELETE FROM login_attempts WHERE user_id = $1 OR email = $2
+`
+
+type DeleteLoginAttemptsOfUserParams struct {
+	UserID pgtype.UUID `json:"user_id"`
+	Email  string      `json:"email"`
+}
+
+func (q *Queries) DeleteLoginAttemptsOfUser(ctx context.Context, arg DeleteLoginAttemptsOfUserParams) error {
+	_, err := q.db.Exec(ctx, deleteLoginAttemptsOfUser, arg.UserID, arg.Email)
+	return err
+}
+
+const deleteNotificationsOfUser = `-- name: DeleteNotificationsOfUser :exec
+DELETE FROM notifications WHERE user_id = $1
+`
+
+func (q *Queries) DeleteNotificationsOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteNotificationsOfUser, userID)
+	return err
+}
+
+const deleteRecoveryCodesOfUser = `-- name: DeleteRecoveryCodesOfUser :exec
+DELETE FROM recovery_codes WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodesOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRecoveryCodesOfUser, userID)
+	return err
+}
+
+const deleteRefreshTokensOfUser = `-- name: DeleteRefreshTokensOfUser :exec
+DELETE FROM refresh_tokens WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRefreshTokensOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRefreshTokensOfUser, userID)
+	return err
+}
+
+const deleteUserIdentitiesOfUser = `-- name: DeleteUserIdentitiesOfUser :exec
+DELETE FROM user_identities WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserIdentitiesOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteUserIdentitiesOfUser, userID)
+	return err
+}
+
+const deleteUserTokensOfUser = `-- name: DeleteUserTokensOfUser :exec
+DELETE FROM user_tokens WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserTokensOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteUserTokensOfUser, userID)
+	return err
+}
+
+const deleteWatchlistOfUser = `-- name: DeleteWatchlistOfUser :exec
+DELETE FROM watchlist WHERE user_id = $1
+`
+
+func (q *Queries) DeleteWatchlistOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteWatchlistOfUser, userID)
+	return err
+}
+
+const deleteWsTicketsOfUser = `-- name: DeleteWsTicketsOfUser :exec
+DELETE FROM ws_tickets WHERE user_id = $1
+`
+
+func (q *Queries) DeleteWsTicketsOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteWsTicketsOfUser, userID)
+	return err
+}
+
+const listBidsByUser = `-- name: ListBidsByUser :many
+SELECT
+	b.id,
+	b.product_id,
+	p.product_name,
+	p.currency,
+	b.bid_amount,
+	b.created_at
+FROM bids b
+JOIN products p ON p.id = b.product_id
+WHERE b.user_id = $1
+ORDER BY b.created_at DESC
+`
+
+type ListBidsByUserRow struct {
+	ID          uuid.UUID      `json:"id"`
+	ProductID   uuid.UUID      `json:"product_id"`
+	ProductName string         `json:"product_name"`
+	Currency    money.Currency `json:"currency"`
+	BidAmount   money.Money    `json:"bid_amount"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+func (q *Queries) ListBidsByUser(ctx context.Context, userID uuid.UUID) ([]ListBidsByUserRow, error) {
+	rows, err := q.db.Query(ctx, listBidsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBidsByUserRow
+	for rows.Next() {
+		var i ListBidsByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.Currency,
+			&i.BidAmount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeedbackByUser = `-- name: ListFeedbackByUser :many
+SELECT
+	f.id,
+	f.product_id,
+	p.product_name,
+	f.role,
+	f.to_user_id,
+	f.rating,
+	f.comment,
+	f.created_at
+FROM feedback f
+JOIN products p ON p.id = f.product_id
+WHERE f.from_user_id = $1
+ORDER BY f.created_at DESC
+`
+
+type ListFeedbackByUserRow struct {
+	ID          uuid.UUID `json:"id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Role        string    `json:"role"`
+	ToUserID    uuid.UUID `json:"to_user_id"`
+	Rating      int16     `json:"rating"`
+	Comment     string    `json:"comment"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// The feedback the user wrote.
+func (q *Queries) ListFeedbackByUser(ctx context.Context, fromUserID uuid.UUID) ([]ListFeedbackByUserRow, error) {
+	rows, err := q.db.Query(ctx, listFeedbackByUser, fromUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFeedbackByUserRow
+	for rows.Next() {
+		var i ListFeedbackByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.Role,
+			&i.ToUserID,
+			&i.Rating,
+			&i.Comment,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLoginAttemptsByUser = `-- name: ListLoginAttemptsByUser :many
+SELECT id, email, user_id, ip_address, outcome, created_at FROM login_attempts
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT 100
+`
+
+func (q *Queries) ListLoginAttemptsByUser(ctx context.Context, userID pgtype.UUID) ([]LoginAttempt, error) {
+	rows, err := q.db.Query(ctx, listLoginAttemptsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoginAttempt
+	for rows.Next() {
+		var i LoginAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.UserID,
+			&i.IpAddress,
+			&i.Outcome,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotificationsByUser = `-- name: ListNotificationsByUser :many
+SELECT id, user_id, kind, payload, status, attempts, last_error, next_attempt_at, delivered_at, created_at, delivered_channels FROM notifications
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListNotificationsByUser(ctx context.Context, userID uuid.UUID) ([]Notification, error) {
+	rows, err := q.db.Query(ctx, listNotificationsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Kind,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+			&i.DeliveredChannels,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProductsBySeller = `-- name: ListProductsBySeller :many
+SELECT id, seller_id, product_name, description, baseprice, auction_end, is_sold, created_at, updated_at, currency, closed_at, closed_by, close_reason FROM products
+WHERE seller_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListProductsBySeller(ctx context.Context, sellerID uuid.UUID) ([]Product, error) {
+	rows, err := q.db.Query(ctx, listProductsBySeller, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.SellerID,
+			&i.ProductName,
+			&i.Description,
+			&i.Baseprice,
+			&i.AuctionEnd,
+			&i.IsSold,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+			&i.ClosedAt,
+			&i.ClosedBy,
+			&i.CloseReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserIdentities = `-- name: ListUserIdentities :many
+SELECT id, user_id, provider, subject, email, last_login_at, created_at FROM user_identities
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListUserIdentities(ctx context.Context, userID uuid.UUID) ([]UserIdentity, error) {
+	rows, err := q.db.Query(ctx, listUserIdentities, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserIdentity
+	for rows.Next() {
+		var i UserIdentity
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Provider,
+			&i.Subject,
+			&i.Email,
+			&i.LastLoginAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWatchlistByUser = `-- name: ListWatchlistByUser :many
+SELECT
+	w.product_id,
+	p.product_name,
+	w.created_at
+FROM watchlist w
+JOIN products p ON p.id = w.product_id
+WHERE w.user_id = $1
+ORDER BY w.created_at DESC
+`
+
+type ListWatchlistByUserRow struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListWatchlistByUser(ctx context.Context, userID uuid.UUID) ([]ListWatchlistByUserRow, error) {
+	rows, err := q.db.Query(ctx, listWatchlistByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWatchlistByUserRow
+	for rows.Next() {
+		var i ListWatchlistByUserRow
+		if err := rows.Scan(&i.ProductID, &i.ProductName, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const scheduleAccountDeletion = `-- name: ScheduleAccountDeletion :exec
+UPDATE users
+SET deletion_scheduled_for = $2, updated_at = now()
+WHERE id = $1
+`
+
+type ScheduleAccountDeletionParams struct {
+	ID                   uuid.UUID          `json:"id"`
+	DeletionScheduledFor pgtype.Timestamptz `json:"deletion_scheduled_for"`
+}
+
+func (q *Queries) ScheduleAccountDeletion(ctx context.Context, arg ScheduleAccountDeletionParams) error {
+	_, err := q.db.Exec(ctx, scheduleAccountDeletion, arg.ID, arg.DeletionScheduledFor)
+	return err
+}
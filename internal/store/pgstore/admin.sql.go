@@ -0,0 +1,209 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: admin.sql
+
+package pgstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+)
+
+const closeAuction = `-- name: CloseAuction :execrows
+UPDATE products
+SET
+	auction_end = now(),
+	closed_at = now(),
+	closed_by = $1,
+	close_reason = $2,
+	updated_at = now()
+WHERE id = $3 AND auction_end > now()
+`
+
+type CloseAuctionParams struct {
+	ClosedBy    pgtype.UUID `json:"closed_by"`
+	CloseReason string      `json:"close_reason"`
+	ID          uuid.UUID   `json:"id"`
+}
+
+func (q *Queries) CloseAuction(ctx context.Context, arg CloseAuctionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, closeAuction, arg.ClosedBy, arg.CloseReason, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getBidHistory = `-- name: GetBidHistory :many
+SELECT
+	b.id,
+	b.user_id,
+	u.user_name,
+	b.bid_amount,
+	b.created_at
+FROM bids b
+JOIN users u ON u.id = b.user_id
+WHERE b.product_id = $1
+ORDER BY b.created_at DESC
+`
+
+type GetBidHistoryRow struct {
+	ID        uuid.UUID   `json:"id"`
+	UserID    uuid.UUID   `json:"user_id"`
+	UserName  string      `json:"user_name"`
+	BidAmount money.Money `json:"bid_amount"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+func (q *Queries) GetBidHistory(ctx context.Context, productID uuid.UUID) ([]GetBidHistoryRow, error) {
+	rows, err := q.db.Query(ctx, getBidHistory, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBidHistoryRow
+	for rows.Next() {
+		var i GetBidHistoryRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.UserName,
+			&i.BidAmount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT
+	id,
+	user_name,
+	email,
+	role,
+	email_verified_at,
+	suspended_at,
+	suspended_until,
+	suspension_reason,
+	created_at
+FROM users
+WHERE $1::TEXT = ''
+	OR strpos(lower(user_name), lower($1)) > 0
+	OR strpos(lower(email), lower($1)) > 0
+ORDER BY created_at DESC
+LIMIT $3 OFFSET $2
+`
+
+type SearchUsersParams struct {
+	Query      string `json:"query"`
+	Skip       int32  `json:"skip"`
+	MaxResults int32  `json:"max_results"`
+}
+
+type SearchUsersRow struct {
+	ID               uuid.UUID          `json:"id"`
+	UserName         string             `json:"user_name"`
+	Email            string             `json:"email"`
+	Role             string             `json:"role"`
+	EmailVerifiedAt  pgtype.Timestamptz `json:"email_verified_at"`
+	SuspendedAt      pgtype.Timestamptz `json:"suspended_at"`
+	SuspendedUntil   pgtype.Timestamptz `json:"suspended_until"`
+	SuspensionReason string             `json:"suspension_reason"`
+	CreatedAt        time.Time          `json:"created_at"`
+}
+
+// Users whose name or email contains the query, ignoring case. strpos takes
+// the query as plain text, unlike a LIKE pattern where % and _ match
+// anything.
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error) {
+	rows, err := q.db.Query(ctx, searchUsers, arg.Query, arg.Skip, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchUsersRow
+	for rows.Next() {
+		var i SearchUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserName,
+			&i.Email,
+			&i.Role,
+			&i.EmailVerifiedAt,
+			&i.SuspendedAt,
+			&i.SuspendedUntil,
+			&i.SuspensionReason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const suspendUser = `-- name: SuspendUser :exec
+UPDATE users
+SET
+	suspended_at = now(),
+	suspended_until = $1,
+	suspension_reason = $2,
+	updated_at = now()
+WHERE id = $3
+`
+
+type SuspendUserParams struct {
+	SuspendedUntil   pgtype.Timestamptz `json:"suspended_until"`
+	SuspensionReason string             `json:"suspension_reason"`
+	ID               uuid.UUID          `json:"id"`
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) error {
+	_, err := q.db.Exec(ctx, suspendUser, arg.SuspendedUntil, arg.SuspensionReason, arg.ID)
+	return err
+}
+
+const unsuspendUser = `-- name: UnsuspendUser :exec
+UPDATE users
+SET
+	suspended_at = NULL,
+	suspended_until = NULL,
+	suspension_reason = '',
+	updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) UnsuspendUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, unsuspendUser, id)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :exec
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE id = $1
+`
+
+type UpdateUserRoleParams struct {
+	ID   uuid.UUID `json:"id"`
+	Role string    `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) error {
+	_, err := q.db.Exec(ctx, updateUserRole, arg.ID, arg.Role)
+	return err
+}
@@ -0,0 +1,152 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: api_tokens.sql
+
+package pgstore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countApiTokens = `-- name: CountApiTokens :one
+SELECT COUNT(*) FROM api_tokens WHERE user_id = $1
+`
+
+func (q *Queries) CountApiTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countApiTokens, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createApiToken = `-- name: CreateApiToken :one
+INSERT INTO api_tokens (
+	user_id,
+	name,
+	token_hash,
+	scopes,
+	expires_at
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5
+) RETURNING id, user_id, name, token_hash, scopes, last_used_at, expires_at, created_at
+`
+
+type CreateApiTokenParams struct {
+	UserID    uuid.UUID          `json:"user_id"`
+	Name      string             `json:"name"`
+	TokenHash []byte             `json:"token_hash"`
+	Scopes    []string           `json:"scopes"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreateApiToken(ctx context.Context, arg CreateApiTokenParams) (ApiToken, error) {
+	row := q.db.QueryRow(ctx, createApiToken,
+		arg.UserID,
+		arg.Name,
+		arg.TokenHash,
+		arg.Scopes,
+		arg.ExpiresAt,
+	)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.LastUsedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteApiToken = `-- name: DeleteApiToken :execrows
+DELETE FROM api_tokens WHERE id = $1 AND user_id = $2
+`
+
+type DeleteApiTokenParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteApiToken(ctx context.Context, arg DeleteApiTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteApiToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getApiTokenByHash = `-- name: GetApiTokenByHash :one
+SELECT id, user_id, name, token_hash, scopes, last_used_at, expires_at, created_at FROM api_tokens
+WHERE token_hash = $1 AND (expires_at IS NULL OR expires_at > now())
+`
+
+func (q *Queries) GetApiTokenByHash(ctx context.Context, tokenHash []byte) (ApiToken, error) {
+	row := q.db.QueryRow(ctx, getApiTokenByHash, tokenHash)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.LastUsedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listApiTokens = `-- name: ListApiTokens :many
+SELECT id, user_id, name, token_hash, scopes, last_used_at, expires_at, created_at FROM api_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListApiTokens(ctx context.Context, userID uuid.UUID) ([]ApiToken, error) {
+	rows, err := q.db.Query(ctx, listApiTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiToken
+	for rows.Next() {
+		var i ApiToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.TokenHash,
+			&i.Scopes,
+			&i.LastUsedAt,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchApiToken = `-- name: TouchApiToken :exec
+UPDATE api_tokens SET last_used_at = now() WHERE id = $1
+`
+
+func (q *Queries) TouchApiToken(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, touchApiToken, id)
+	return err
+}
@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
 )
 
 const createBid = `-- name: CreateBid :one
@@ -24,9 +25,9 @@ INSERT INTO bids (
 `
 
 type CreateBidParams struct {
-	ProductID uuid.UUID `json:"product_id"`
-	UserID    uuid.UUID `json:"user_id"`
-	BidAmount float64   `json:"bid_amount"`
+	ProductID uuid.UUID   `json:"product_id"`
+	UserID    uuid.UUID   `json:"user_id"`
+	BidAmount money.Money `json:"bid_amount"`
 }
 
 func (q *Queries) CreateBid(ctx context.Context, arg CreateBidParams) (Bid, error) {
@@ -0,0 +1,133 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: credit_limits.sql
+
+package pgstore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+)
+
+const deleteCreditLimit = `-- name: DeleteCreditLimit :exec
+DELETE FROM credit_limits WHERE user_id = $1 AND currency = $2
+`
+
+type DeleteCreditLimitParams struct {
+	UserID   uuid.UUID      `json:"user_id"`
+	Currency money.Currency `json:"currency"`
+}
+
+func (q *Queries) DeleteCreditLimit(ctx context.Context, arg DeleteCreditLimitParams) error {
+	_, err := q.db.Exec(ctx, deleteCreditLimit, arg.UserID, arg.Currency)
+	return err
+}
+
+const getCreditExposure = `-- name: GetCreditExposure :one
+SELECT COALESCE(SUM(hb.bid_amount), 0)::BIGINT AS exposure
+FROM products p
+JOIN LATERAL (
+	SELECT b.user_id, b.bid_amount FROM bids b
+	WHERE b.product_id = p.id
+	ORDER BY b.bid_amount DESC
+	LIMIT 1
+) hb ON TRUE
+WHERE p.currency = $1
+	AND p.closed_at IS NULL
+	AND p.id <> $2
+	AND hb.user_id = $3::UUID
+`
+
+type GetCreditExposureParams struct {
+	Currency          money.Currency `json:"currency"`
+	ExcludedProductID uuid.UUID      `json:"excluded_product_id"`
+	UserID            uuid.UUID      `json:"user_id"`
+}
+
+// The sum of the highest bids of the user on the auctions they lead or won
+// in the currency, leaving out one product. Won auctions stay in until
+// payments are settled, which they aren't yet, so winning doesn't free credit.
+func (q *Queries) GetCreditExposure(ctx context.Context, arg GetCreditExposureParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getCreditExposure, arg.Currency, arg.ExcludedProductID, arg.UserID)
+	var exposure int64
+	err := row.Scan(&exposure)
+	return exposure, err
+}
+
+const getCreditLimitForUpdate = `-- name: GetCreditLimitForUpdate :one
+SELECT amount FROM credit_limits
+WHERE user_id = $1 AND currency = $2
+FOR UPDATE
+`
+
+type GetCreditLimitForUpdateParams struct {
+	UserID   uuid.UUID      `json:"user_id"`
+	Currency money.Currency `json:"currency"`
+}
+
+// Locking the limit serializes the bids of the user in the currency, so
+// they can't go over it together.
+func (q *Queries) GetCreditLimitForUpdate(ctx context.Context, arg GetCreditLimitForUpdateParams) (money.Money, error) {
+	row := q.db.QueryRow(ctx, getCreditLimitForUpdate, arg.UserID, arg.Currency)
+	var amount money.Money
+	err := row.Scan(&amount)
+	return amount, err
+}
+
+const listCreditLimits = `-- name: ListCreditLimits :many
+SELECT user_id, currency, amount, updated_at FROM credit_limits
+WHERE user_id = $1
+ORDER BY currency
+`
+
+func (q *Queries) ListCreditLimits(ctx context.Context, userID uuid.UUID) ([]CreditLimit, error) {
+	rows, err := q.db.Query(ctx, listCreditLimits, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreditLimit
+	for rows.Next() {
+		var i CreditLimit
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Currency,
+			&i.Amount,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setCreditLimit = `-- name: SetCreditLimit :exec
+INSERT INTO credit_limits (
+	user_id,
+	currency,
+	amount
+) VALUES (
+	$1,
+	$2,
+	$3
+) ON CONFLICT (user_id, currency) DO UPDATE
+SET amount = EXCLUDED.amount, updated_at = now()
+`
+
+type SetCreditLimitParams struct {
+	UserID   uuid.UUID      `json:"user_id"`
+	Currency money.Currency `json:"currency"`
+	Amount   money.Money    `json:"amount"`
+}
+
+func (q *Queries) SetCreditLimit(ctx context.Context, arg SetCreditLimitParams) error {
+	_, err := q.db.Exec(ctx, setCreditLimit, arg.UserID, arg.Currency, arg.Amount)
+	return err
+}
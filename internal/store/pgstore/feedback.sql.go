@@ -0,0 +1,159 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: feedback.sql
+
+package pgstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createFeedback = `-- name: CreateFeedback :execrows
+INSERT INTO feedback (
+	product_id,
+	role,
+	from_user_id,
+	to_user_id,
+	rating,
+	comment
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6
+) ON CONFLICT (product_id, role) DO NOTHING
+`
+
+type CreateFeedbackParams struct {
+	ProductID  uuid.UUID `json:"product_id"`
+	Role       string    `json:"role"`
+	FromUserID uuid.UUID `json:"from_user_id"`
+	ToUserID   uuid.UUID `json:"to_user_id"`
+	Rating     int16     `json:"rating"`
+	Comment    string    `json:"comment"`
+}
+
+func (q *Queries) CreateFeedback(ctx context.Context, arg CreateFeedbackParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createFeedback,
+		arg.ProductID,
+		arg.Role,
+		arg.FromUserID,
+		arg.ToUserID,
+		arg.Rating,
+		arg.Comment,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getReputation = `-- name: GetReputation :one
+SELECT
+	COUNT(*) AS ratings,
+	COALESCE(AVG(rating), 0)::float8 AS average,
+	COUNT(*) FILTER (WHERE role = 'buyer') AS seller_ratings,
+	COALESCE(AVG(rating) FILTER (WHERE role = 'buyer'), 0)::float8 AS seller_average,
+	COUNT(*) FILTER (WHERE role = 'seller') AS buyer_ratings,
+	COALESCE(AVG(rating) FILTER (WHERE role = 'seller'), 0)::float8 AS buyer_average
+FROM feedback
+WHERE to_user_id = $1
+`
+
+type GetReputationRow struct {
+	Ratings       int64   `json:"ratings"`
+	Average       float64 `json:"average"`
+	SellerRatings int64   `json:"seller_ratings"`
+	SellerAverage float64 `json:"seller_average"`
+	BuyerRatings  int64   `json:"buyer_ratings"`
+	BuyerAverage  float64 `json:"buyer_average"`
+}
+
+// Ratings received, overall and split by the part the user played: the
+// ones left by buyers rate the user as a seller.
+func (q *Queries) GetReputation(ctx context.Context, toUserID uuid.UUID) (GetReputationRow, error) {
+	row := q.db.QueryRow(ctx, getReputation, toUserID)
+	var i GetReputationRow
+	err := row.Scan(
+		&i.Ratings,
+		&i.Average,
+		&i.SellerRatings,
+		&i.SellerAverage,
+		&i.BuyerRatings,
+		&i.BuyerAverage,
+	)
+	return i, err
+}
+
+const listFeedbackForUser = `-- name: ListFeedbackForUser :many
+SELECT
+	f.id,
+	f.product_id,
+	p.product_name,
+	f.role,
+	f.from_user_id,
+	u.user_name AS from_user_name,
+	f.rating,
+	f.comment,
+	f.created_at
+FROM feedback f
+JOIN products p ON p.id = f.product_id
+JOIN users u ON u.id = f.from_user_id
+WHERE f.to_user_id = $1
+ORDER BY f.created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListFeedbackForUserParams struct {
+	ToUserID uuid.UUID `json:"to_user_id"`
+	Limit    int32     `json:"limit"`
+	Offset   int32     `json:"offset"`
+}
+
+type ListFeedbackForUserRow struct {
+	ID           uuid.UUID `json:"id"`
+	ProductID    uuid.UUID `json:"product_id"`
+	ProductName  string    `json:"product_name"`
+	Role         string    `json:"role"`
+	FromUserID   uuid.UUID `json:"from_user_id"`
+	FromUserName string    `json:"from_user_name"`
+	Rating       int16     `json:"rating"`
+	Comment      string    `json:"comment"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListFeedbackForUser(ctx context.Context, arg ListFeedbackForUserParams) ([]ListFeedbackForUserRow, error) {
+	rows, err := q.db.Query(ctx, listFeedbackForUser, arg.ToUserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFeedbackForUserRow
+	for rows.Next() {
+		var i ListFeedbackForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.Role,
+			&i.FromUserID,
+			&i.FromUserName,
+			&i.Rating,
+			&i.Comment,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
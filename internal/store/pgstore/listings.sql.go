@@ -0,0 +1,287 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: listings.sql
+
+package pgstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+)
+
+const countSellerListingsByStatus = `-- name: CountSellerListingsByStatus :one
+SELECT
+	COUNT(*) FILTER (WHERE p.closed_at IS NULL AND p.auction_end > now()) AS live,
+	COUNT(*) FILTER (
+		WHERE p.closed_at IS NULL AND p.auction_end <= now() AND (
+			p.is_sold OR EXISTS (SELECT 1 FROM bids b WHERE b.product_id = p.id)
+		)
+	) AS sold,
+	COUNT(*) FILTER (
+		WHERE p.closed_at IS NULL AND p.auction_end <= now() AND NOT p.is_sold
+			AND NOT EXISTS (SELECT 1 FROM bids b WHERE b.product_id = p.id)
+	) AS unsold,
+	COUNT(*) FILTER (WHERE p.closed_at IS NOT NULL) AS closed
+FROM products p
+WHERE p.seller_id = $1
+`
+
+type CountSellerListingsByStatusRow struct {
+	Live   int64 `json:"live"`
+	Sold   int64 `json:"sold"`
+	Unsold int64 `json:"unsold"`
+	Closed int64 `json:"closed"`
+}
+
+func (q *Queries) CountSellerListingsByStatus(ctx context.Context, sellerID uuid.UUID) (CountSellerListingsByStatusRow, error) {
+	row := q.db.QueryRow(ctx, countSellerListingsByStatus, sellerID)
+	var i CountSellerListingsByStatusRow
+	err := row.Scan(
+		&i.Live,
+		&i.Sold,
+		&i.Unsold,
+		&i.Closed,
+	)
+	return i, err
+}
+
+const getSellerRevenue = `-- name: GetSellerRevenue :many
+SELECT
+	p.currency,
+	SUM(hb.bid_amount)::BIGINT AS revenue
+FROM products p
+JOIN LATERAL (
+	SELECT b.bid_amount FROM bids b
+	WHERE b.product_id = p.id
+	ORDER BY b.bid_amount DESC
+	LIMIT 1
+) hb ON TRUE
+WHERE p.seller_id = $1 AND p.closed_at IS NULL AND p.auction_end <= now()
+GROUP BY p.currency
+ORDER BY p.currency
+`
+
+type GetSellerRevenueRow struct {
+	Currency money.Currency `json:"currency"`
+	Revenue  int64          `json:"revenue"`
+}
+
+// Revenue of the sold products, the winning bids, by currency.
+func (q *Queries) GetSellerRevenue(ctx context.Context, sellerID uuid.UUID) ([]GetSellerRevenueRow, error) {
+	rows, err := q.db.Query(ctx, getSellerRevenue, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSellerRevenueRow
+	for rows.Next() {
+		var i GetSellerRevenueRow
+		if err := rows.Scan(&i.Currency, &i.Revenue); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBidderActivity = `-- name: ListBidderActivity :many
+SELECT id, product_name, currency, auction_end, my_max_bid, my_bids, last_bid_at, current_price, state FROM (
+	SELECT
+		p.id,
+		p.product_name,
+		p.currency,
+		p.auction_end,
+		mb.max_bid::BIGINT AS my_max_bid,
+		mb.bid_count::BIGINT AS my_bids,
+		mb.last_bid_at::TIMESTAMPTZ AS last_bid_at,
+		hb.bid_amount::BIGINT AS current_price,
+		(CASE
+			WHEN p.closed_at IS NOT NULL THEN 'closed'
+			WHEN p.auction_end > now() AND hb.user_id = $1::UUID THEN 'winning'
+			WHEN p.auction_end > now() THEN 'outbid'
+			WHEN hb.user_id = $1::UUID THEN 'won'
+			ELSE 'lost'
+		END)::TEXT AS state
+	FROM products p
+	JOIN (
+		SELECT
+			b.product_id,
+			MAX(b.bid_amount) AS max_bid,
+			COUNT(*) AS bid_count,
+			MAX(b.created_at) AS last_bid_at
+		FROM bids b
+		WHERE b.user_id = $1
+		GROUP BY b.product_id
+	) mb ON mb.product_id = p.id
+	JOIN LATERAL (
+		SELECT b.user_id, b.bid_amount FROM bids b
+		WHERE b.product_id = p.id
+		ORDER BY b.bid_amount DESC
+		LIMIT 1
+	) hb ON TRUE
+) a
+WHERE $2::TEXT = '' OR a.state = $2
+ORDER BY a.auction_end DESC
+LIMIT $4 OFFSET $3
+`
+
+type ListBidderActivityParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	State      string    `json:"state"`
+	Skip       int32     `json:"skip"`
+	MaxResults int32     `json:"max_results"`
+}
+
+type ListBidderActivityRow struct {
+	ID           uuid.UUID      `json:"id"`
+	ProductName  string         `json:"product_name"`
+	Currency     money.Currency `json:"currency"`
+	AuctionEnd   time.Time      `json:"auction_end"`
+	MyMaxBid     int64          `json:"my_max_bid"`
+	MyBids       int64          `json:"my_bids"`
+	LastBidAt    time.Time      `json:"last_bid_at"`
+	CurrentPrice int64          `json:"current_price"`
+	State        string         `json:"state"`
+}
+
+// The products a user bid on. Auctions closed by a moderator are "closed",
+// the others are "winning" or "outbid" until their end and then "won" or
+// "lost".
+func (q *Queries) ListBidderActivity(ctx context.Context, arg ListBidderActivityParams) ([]ListBidderActivityRow, error) {
+	rows, err := q.db.Query(ctx, listBidderActivity,
+		arg.UserID,
+		arg.State,
+		arg.Skip,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBidderActivityRow
+	for rows.Next() {
+		var i ListBidderActivityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductName,
+			&i.Currency,
+			&i.AuctionEnd,
+			&i.MyMaxBid,
+			&i.MyBids,
+			&i.LastBidAt,
+			&i.CurrentPrice,
+			&i.State,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSellerListings = `-- name: ListSellerListings :many
+SELECT id, product_name, currency, auction_end, created_at, current_price, bid_count, unique_bidders, watchers, status FROM (
+	SELECT
+		p.id,
+		p.product_name,
+		p.currency,
+		p.auction_end,
+		p.created_at,
+		COALESCE(hb.bid_amount, p.baseprice)::BIGINT AS current_price,
+		COALESCE(bs.bid_count, 0)::BIGINT AS bid_count,
+		COALESCE(bs.unique_bidders, 0)::BIGINT AS unique_bidders,
+		(SELECT COUNT(*) FROM watchlist w WHERE w.product_id = p.id)::BIGINT AS watchers,
+		(CASE
+			WHEN p.closed_at IS NOT NULL THEN 'closed'
+			WHEN p.auction_end > now() THEN 'live'
+			WHEN p.is_sold OR hb.bid_amount IS NOT NULL THEN 'sold'
+			ELSE 'unsold'
+		END)::TEXT AS status
+	FROM products p
+	LEFT JOIN LATERAL (
+		SELECT b.bid_amount FROM bids b
+		WHERE b.product_id = p.id
+		ORDER BY b.bid_amount DESC
+		LIMIT 1
+	) hb ON TRUE
+	LEFT JOIN LATERAL (
+		SELECT COUNT(*) AS bid_count, COUNT(DISTINCT b.user_id) AS unique_bidders
+		FROM bids b
+		WHERE b.product_id = p.id
+	) bs ON TRUE
+	WHERE p.seller_id = $1
+) l
+WHERE $2::TEXT = '' OR l.status = $2
+	OR ($2 = 'ended' AND l.status <> 'live')
+ORDER BY l.auction_end DESC
+LIMIT $4 OFFSET $3
+`
+
+type ListSellerListingsParams struct {
+	SellerID   uuid.UUID `json:"seller_id"`
+	Status     string    `json:"status"`
+	Skip       int32     `json:"skip"`
+	MaxResults int32     `json:"max_results"`
+}
+
+type ListSellerListingsRow struct {
+	ID            uuid.UUID      `json:"id"`
+	ProductName   string         `json:"product_name"`
+	Currency      money.Currency `json:"currency"`
+	AuctionEnd    time.Time      `json:"auction_end"`
+	CreatedAt     time.Time      `json:"created_at"`
+	CurrentPrice  int64          `json:"current_price"`
+	BidCount      int64          `json:"bid_count"`
+	UniqueBidders int64          `json:"unique_bidders"`
+	Watchers      int64          `json:"watchers"`
+	Status        string         `json:"status"`
+}
+
+// Products closed by a moderator are "closed", the others are "live" until
+// their end and then "sold" or "unsold" depending on whether they got bids.
+// Filtering on "ended" returns every finished product, whatever its status.
+func (q *Queries) ListSellerListings(ctx context.Context, arg ListSellerListingsParams) ([]ListSellerListingsRow, error) {
+	rows, err := q.db.Query(ctx, listSellerListings,
+		arg.SellerID,
+		arg.Status,
+		arg.Skip,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSellerListingsRow
+	for rows.Next() {
+		var i ListSellerListingsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductName,
+			&i.Currency,
+			&i.AuctionEnd,
+			&i.CreatedAt,
+			&i.CurrentPrice,
+			&i.BidCount,
+			&i.UniqueBidders,
+			&i.Watchers,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
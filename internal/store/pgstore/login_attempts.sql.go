@@ -0,0 +1,116 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: login_attempts.sql
+
+package pgstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getEmailLoginFailures = `-- name: GetEmailLoginFailures :one
+SELECT
+	COUNT(*) AS failures,
+	COALESCE(MAX(a.created_at), 'epoch')::TIMESTAMPTZ AS last_failure
+FROM login_attempts a
+WHERE a.email = $1
+	AND a.outcome = 'failure'
+	AND a.created_at > now() - INTERVAL '1 day'
+	AND a.created_at > COALESCE((
+		SELECT MAX(s.created_at) FROM login_attempts s
+		WHERE s.email = $1 AND s.outcome = 'success'
+	), 'epoch')
+`
+
+type GetEmailLoginFailuresRow struct {
+	Failures    int64     `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+// Failures since the last successful login, within the last day.
+func (q *Queries) GetEmailLoginFailures(ctx context.Context, email string) (GetEmailLoginFailuresRow, error) {
+	row := q.db.QueryRow(ctx, getEmailLoginFailures, email)
+	var i GetEmailLoginFailuresRow
+	err := row.Scan(&i.Failures, &i.LastFailure)
+	return i, err
+}
+
+const getIPLoginFailures = `-- name: GetIPLoginFailures :one
+SELECT
+	COUNT(*) AS failures,
+	COALESCE(MAX(a.created_at), 'epoch')::TIMESTAMPTZ AS last_failure
+FROM login_attempts a
+WHERE a.ip_address = $1
+	AND a.outcome = 'failure'
+	AND a.created_at > now() - INTERVAL '1 hour'
+`
+
+type GetIPLoginFailuresRow struct {
+	Failures    int64     `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+// Failures within the last hour. Successful logins don't reset them, or an
+// attacker could clear the count by logging into an account of their own.
+func (q *Queries) GetIPLoginFailures(ctx context.Context, ipAddress string) (GetIPLoginFailuresRow, error) {
+	row := q.db.QueryRow(ctx, getIPLoginFailures, ipAddress)
+	var i GetIPLoginFailuresRow
+	err := row.Scan(&i.Failures, &i.LastFailure)
+	return i, err
+}
+
+const lockLoginEmail = `-- name: LockLoginEmail :exec
+SELECT pg_advisory_xact_lock(hashtextextended('login_email:' || $1::TEXT, 0))
+`
+
+// Held until the end of the transaction, so attempts for the same email are
+// checked and recorded one after the other.
+func (q *Queries) LockLoginEmail(ctx context.Context, email string) error {
+	_, err := q.db.Exec(ctx, lockLoginEmail, email)
+	return err
+}
+
+const lockLoginIP = `-- name: LockLoginIP :exec
+SELECT pg_advisory_xact_lock(hashtextextended('login_ip:' || $1::TEXT, 0))
+`
+
+// The same for attempts from the same IP address.
+func (q *Queries) LockLoginIP(ctx context.Context, ipAddress string) error {
+	_, err := q.db.Exec(ctx, lockLoginIP, ipAddress)
+	return err
+}
+
+const recordLoginAttempt = `-- name: RecordLoginAttempt :exec
+INSERT INTO login_attempts (
+	email,
+	user_id,
+	ip_address,
+	outcome
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+)
+`
+
+type RecordLoginAttemptParams struct {
+	Email     string      `json:"email"`
+	UserID    pgtype.UUID `json:"user_id"`
+	IpAddress string      `json:"ip_address"`
+	Outcome   string      `json:"outcome"`
+}
+
+func (q *Queries) RecordLoginAttempt(ctx context.Context, arg RecordLoginAttemptParams) error {
+	_, err := q.db.Exec(ctx, recordLoginAttempt,
+		arg.Email,
+		arg.UserID,
+		arg.IpAddress,
+		arg.Outcome,
+	)
+	return err
+}
@@ -0,0 +1,15 @@
+-- Write your migrate up statements here
+ALTER TABLE products
+	ALTER COLUMN baseprice TYPE BIGINT USING round(baseprice * 100)::BIGINT;
+
+ALTER TABLE bids
+	ALTER COLUMN bid_amount TYPE BIGINT USING round(bid_amount * 100)::BIGINT;
+---- create above / drop below ----
+ALTER TABLE bids
+	ALTER COLUMN bid_amount TYPE FLOAT USING bid_amount / 100.0;
+
+ALTER TABLE products
+	ALTER COLUMN baseprice TYPE FLOAT USING baseprice / 100.0;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,13 @@
+-- Write your migrate up statements here
+ALTER TABLE products
+	ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE users
+	ADD COLUMN display_currency TEXT CHECK (display_currency ~ '^[A-Z]{3}$');
+---- create above / drop below ----
+ALTER TABLE users DROP COLUMN IF EXISTS display_currency;
+
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,17 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS watchlist (
+	user_id UUID NOT NULL REFERENCES users(id),
+	product_id UUID NOT NULL REFERENCES products(id),
+
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+	PRIMARY KEY (user_id, product_id)
+);
+
+CREATE INDEX watchlist_product_id_idx ON watchlist (product_id);
+---- create above / drop below ----
+DROP INDEX IF EXISTS watchlist_product_id_idx;
+DROP TABLE IF EXISTS watchlist;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,23 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS notifications (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL REFERENCES users(id),
+	kind TEXT NOT NULL,
+	payload JSONB NOT NULL,
+
+	status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	delivered_at TIMESTAMPTZ,
+
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX notifications_pending_idx ON notifications (next_attempt_at) WHERE status = 'pending';
+---- create above / drop below ----
+DROP INDEX IF EXISTS notifications_pending_idx;
+DROP TABLE IF EXISTS notifications;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
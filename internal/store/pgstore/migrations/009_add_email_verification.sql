@@ -0,0 +1,26 @@
+-- Write your migrate up statements here
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+-- Accounts created before verification existed keep working.
+UPDATE users SET email_verified_at = created_at;
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL REFERENCES users(id),
+	purpose TEXT NOT NULL,
+	token_hash BYTEA UNIQUE NOT NULL,
+
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX user_tokens_user_id_idx ON user_tokens (user_id, purpose);
+---- create above / drop below ----
+DROP INDEX IF EXISTS user_tokens_user_id_idx;
+DROP TABLE IF EXISTS user_tokens;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,21 @@
+-- Write your migrate up statements here
+-- Indexes the scs sessions by user. Sessions missing from it are treated as
+-- revoked, so sessions opened before it existed have to log in again.
+CREATE TABLE IF NOT EXISTS user_sessions (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	token TEXT UNIQUE NOT NULL,
+	user_id UUID NOT NULL REFERENCES users(id),
+	user_agent TEXT NOT NULL DEFAULT '',
+	ip_address TEXT NOT NULL DEFAULT '',
+
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX user_sessions_user_id_idx ON user_sessions (user_id);
+---- create above / drop below ----
+DROP INDEX IF EXISTS user_sessions_user_id_idx;
+DROP TABLE IF EXISTS user_sessions;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
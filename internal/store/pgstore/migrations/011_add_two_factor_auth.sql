@@ -0,0 +1,28 @@
+-- Write your migrate up statements here
+ALTER TABLE users
+	ADD COLUMN totp_secret TEXT,
+	ADD COLUMN totp_pending_secret TEXT,
+	ADD COLUMN totp_enabled_at TIMESTAMPTZ,
+	ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL REFERENCES users(id),
+	code_hash BYTEA NOT NULL,
+
+	used_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+	UNIQUE (user_id, code_hash)
+);
+---- create above / drop below ----
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE users
+	DROP COLUMN IF EXISTS totp_last_step,
+	DROP COLUMN IF EXISTS totp_enabled_at,
+	DROP COLUMN IF EXISTS totp_pending_secret,
+	DROP COLUMN IF EXISTS totp_secret;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
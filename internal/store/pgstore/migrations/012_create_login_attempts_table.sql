@@ -0,0 +1,20 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS login_attempts (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	email TEXT NOT NULL,
+	user_id UUID REFERENCES users(id),
+	ip_address TEXT NOT NULL,
+	outcome TEXT NOT NULL CHECK (outcome IN ('success', 'failure', 'locked')),
+
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX login_attempts_email_idx ON login_attempts (email, created_at);
+CREATE INDEX login_attempts_ip_address_idx ON login_attempts (ip_address, created_at);
+---- create above / drop below ----
+DROP INDEX IF EXISTS login_attempts_ip_address_idx;
+DROP INDEX IF EXISTS login_attempts_email_idx;
+DROP TABLE IF EXISTS login_attempts;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
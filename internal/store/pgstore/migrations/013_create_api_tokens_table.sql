@@ -0,0 +1,22 @@
+-- Write your migrate up statements here
+-- Personal tokens for scripts and bots. Only the sha256 hash of the token is
+-- kept, the token itself is shown once when it is created.
+CREATE TABLE IF NOT EXISTS api_tokens (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL REFERENCES users(id),
+	name TEXT NOT NULL,
+	token_hash BYTEA UNIQUE NOT NULL,
+	scopes TEXT[] NOT NULL,
+
+	last_used_at TIMESTAMPTZ,
+	expires_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX api_tokens_user_id_idx ON api_tokens (user_id);
+---- create above / drop below ----
+DROP INDEX IF EXISTS api_tokens_user_id_idx;
+DROP TABLE IF EXISTS api_tokens;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
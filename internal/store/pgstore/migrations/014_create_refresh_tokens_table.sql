@@ -0,0 +1,25 @@
+-- Write your migrate up statements here
+-- Refresh tokens are single use. Every refresh replaces the token with a new
+-- one of the same family, and presenting a used token again revokes the
+-- whole family, as it was most likely stolen.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL REFERENCES users(id),
+	family_id UUID NOT NULL,
+	token_hash BYTEA UNIQUE NOT NULL,
+
+	used_at TIMESTAMPTZ,
+	revoked_at TIMESTAMPTZ,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+---- create above / drop below ----
+DROP INDEX IF EXISTS refresh_tokens_user_id_idx;
+DROP INDEX IF EXISTS refresh_tokens_family_id_idx;
+DROP TABLE IF EXISTS refresh_tokens;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
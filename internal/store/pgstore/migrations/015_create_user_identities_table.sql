@@ -0,0 +1,21 @@
+-- Write your migrate up statements here
+-- Accounts at external identity providers users log in with, identified by
+-- the stable subject of the provider rather than the email.
+CREATE TABLE IF NOT EXISTS user_identities (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL REFERENCES users(id),
+	provider TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	email TEXT NOT NULL,
+
+	last_login_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+	UNIQUE (provider, subject),
+	UNIQUE (user_id, provider)
+);
+---- create above / drop below ----
+DROP TABLE IF EXISTS user_identities;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
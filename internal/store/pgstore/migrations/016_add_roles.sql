@@ -0,0 +1,22 @@
+-- Write your migrate up statements here
+ALTER TABLE users
+	ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin')),
+	ADD COLUMN suspended_at TIMESTAMPTZ;
+
+-- Auctions closed by a moderator before their end, which have no winner.
+ALTER TABLE products
+	ADD COLUMN closed_at TIMESTAMPTZ,
+	ADD COLUMN closed_by UUID REFERENCES users(id),
+	ADD COLUMN close_reason TEXT NOT NULL DEFAULT '';
+---- create above / drop below ----
+ALTER TABLE products
+	DROP COLUMN IF EXISTS close_reason,
+	DROP COLUMN IF EXISTS closed_by,
+	DROP COLUMN IF EXISTS closed_at;
+
+ALTER TABLE users
+	DROP COLUMN IF EXISTS suspended_at,
+	DROP COLUMN IF EXISTS role;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,12 @@
+-- Write your migrate up statements here
+-- A suspension without an end is a ban.
+ALTER TABLE users
+	ADD COLUMN suspended_until TIMESTAMPTZ,
+	ADD COLUMN suspension_reason TEXT NOT NULL DEFAULT '';
+---- create above / drop below ----
+ALTER TABLE users
+	DROP COLUMN IF EXISTS suspension_reason,
+	DROP COLUMN IF EXISTS suspended_until;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,18 @@
+-- Write your migrate up statements here
+-- Accounts are anonymized once deletion_scheduled_for passes, keeping their
+-- products and bids. Until then the deletion can be undone.
+ALTER TABLE users
+	ADD COLUMN deletion_scheduled_for TIMESTAMPTZ,
+	ADD COLUMN anonymized_at TIMESTAMPTZ;
+
+CREATE INDEX users_deletion_scheduled_for_idx ON users (deletion_scheduled_for)
+WHERE deletion_scheduled_for IS NOT NULL AND anonymized_at IS NULL;
+---- create above / drop below ----
+DROP INDEX IF EXISTS users_deletion_scheduled_for_idx;
+
+ALTER TABLE users
+	DROP COLUMN IF EXISTS anonymized_at,
+	DROP COLUMN IF EXISTS deletion_scheduled_for;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,20 @@
+-- Write your migrate up statements here
+-- Single use tickets that authenticate a websocket connection for clients
+-- that can't send cookies or headers with it, like browsers on another
+-- origin. Only the sha256 hash of the ticket is kept.
+CREATE TABLE IF NOT EXISTS ws_tickets (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL REFERENCES users(id),
+	ticket_hash BYTEA UNIQUE NOT NULL,
+
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ws_tickets_expires_at_idx ON ws_tickets (expires_at);
+---- create above / drop below ----
+DROP INDEX IF EXISTS ws_tickets_expires_at_idx;
+DROP TABLE IF EXISTS ws_tickets;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
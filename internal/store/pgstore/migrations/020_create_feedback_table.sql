@@ -0,0 +1,24 @@
+-- Write your migrate up statements here
+-- Ratings the buyer and the seller of a sold product leave each other. role
+-- is the part the author played in the sale, so each side rates once.
+CREATE TABLE IF NOT EXISTS feedback (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	product_id UUID NOT NULL REFERENCES products(id),
+	role TEXT NOT NULL CHECK (role IN ('buyer', 'seller')),
+	from_user_id UUID NOT NULL REFERENCES users(id),
+	to_user_id UUID NOT NULL REFERENCES users(id),
+	rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+	comment TEXT NOT NULL DEFAULT '',
+
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+	UNIQUE (product_id, role)
+);
+
+CREATE INDEX feedback_to_user_id_idx ON feedback (to_user_id, created_at DESC);
+---- create above / drop below ----
+DROP INDEX IF EXISTS feedback_to_user_id_idx;
+DROP TABLE IF EXISTS feedback;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
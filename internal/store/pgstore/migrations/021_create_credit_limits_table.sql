@@ -0,0 +1,17 @@
+-- Write your migrate up statements here
+-- The most a user can owe at once in a currency, summing the auctions they
+-- lead. Users without a limit in a currency can bid without one.
+CREATE TABLE IF NOT EXISTS credit_limits (
+	user_id UUID NOT NULL REFERENCES users(id),
+	currency TEXT NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+	amount BIGINT NOT NULL CHECK (amount >= 0),
+
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+	PRIMARY KEY (user_id, currency)
+);
+---- create above / drop below ----
+DROP TABLE IF EXISTS credit_limits;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,11 @@
+-- Write your migrate up statements here
+-- The channels a notification was delivered through, so retries only go
+-- through the ones that failed.
+ALTER TABLE notifications
+	ADD COLUMN delivered_channels TEXT[] NOT NULL DEFAULT '{}';
+---- create above / drop below ----
+ALTER TABLE notifications
+	DROP COLUMN IF EXISTS delivered_channels;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
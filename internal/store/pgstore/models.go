@@ -8,26 +8,103 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
 )
 
+type ApiToken struct {
+	ID         uuid.UUID          `json:"id"`
+	UserID     uuid.UUID          `json:"user_id"`
+	Name       string             `json:"name"`
+	TokenHash  []byte             `json:"token_hash"`
+	Scopes     []string           `json:"scopes"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
 type Bid struct {
-	ID        uuid.UUID `json:"id"`
-	ProductID uuid.UUID `json:"product_id"`
-	UserID    uuid.UUID `json:"user_id"`
-	BidAmount float64   `json:"bid_amount"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID   `json:"id"`
+	ProductID uuid.UUID   `json:"product_id"`
+	UserID    uuid.UUID   `json:"user_id"`
+	BidAmount money.Money `json:"bid_amount"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type CreditLimit struct {
+	UserID    uuid.UUID      `json:"user_id"`
+	Currency  money.Currency `json:"currency"`
+	Amount    money.Money    `json:"amount"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type Feedback struct {
+	ID         uuid.UUID `json:"id"`
+	ProductID  uuid.UUID `json:"product_id"`
+	Role       string    `json:"role"`
+	FromUserID uuid.UUID `json:"from_user_id"`
+	ToUserID   uuid.UUID `json:"to_user_id"`
+	Rating     int16     `json:"rating"`
+	Comment    string    `json:"comment"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type LoginAttempt struct {
+	ID        uuid.UUID   `json:"id"`
+	Email     string      `json:"email"`
+	UserID    pgtype.UUID `json:"user_id"`
+	IpAddress string      `json:"ip_address"`
+	Outcome   string      `json:"outcome"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type Notification struct {
+	ID                uuid.UUID          `json:"id"`
+	UserID            uuid.UUID          `json:"user_id"`
+	Kind              string             `json:"kind"`
+	Payload           []byte             `json:"payload"`
+	Status            string             `json:"status"`
+	Attempts          int32              `json:"attempts"`
+	LastError         string             `json:"last_error"`
+	NextAttemptAt     time.Time          `json:"next_attempt_at"`
+	DeliveredAt       pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt         time.Time          `json:"created_at"`
+	DeliveredChannels []string           `json:"delivered_channels"`
 }
 
 type Product struct {
-	ID          uuid.UUID `json:"id"`
-	SellerID    uuid.UUID `json:"seller_id"`
-	ProductName string    `json:"product_name"`
-	Description string    `json:"description"`
-	Baseprice   float64   `json:"baseprice"`
-	AuctionEnd  time.Time `json:"auction_end"`
-	IsSold      bool      `json:"is_sold"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID          `json:"id"`
+	SellerID    uuid.UUID          `json:"seller_id"`
+	ProductName string             `json:"product_name"`
+	Description string             `json:"description"`
+	Baseprice   money.Money        `json:"baseprice"`
+	AuctionEnd  time.Time          `json:"auction_end"`
+	IsSold      bool               `json:"is_sold"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Currency    money.Currency     `json:"currency"`
+	ClosedAt    pgtype.Timestamptz `json:"closed_at"`
+	ClosedBy    pgtype.UUID        `json:"closed_by"`
+	CloseReason string             `json:"close_reason"`
+}
+
+type RecoveryCode struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	CodeHash  []byte             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type RefreshToken struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	FamilyID  uuid.UUID          `json:"family_id"`
+	TokenHash []byte             `json:"token_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	CreatedAt time.Time          `json:"created_at"`
 }
 
 type Session struct {
@@ -37,11 +114,67 @@ type Session struct {
 }
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	UserName     string    `json:"user_name"`
-	Email        string    `json:"email"`
-	PasswordHash []byte    `json:"password_hash"`
-	Bio          string    `json:"bio"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                   uuid.UUID          `json:"id"`
+	UserName             string             `json:"user_name"`
+	Email                string             `json:"email"`
+	PasswordHash         []byte             `json:"password_hash"`
+	Bio                  string             `json:"bio"`
+	CreatedAt            time.Time          `json:"created_at"`
+	UpdatedAt            time.Time          `json:"updated_at"`
+	DisplayCurrency      *money.Currency    `json:"display_currency"`
+	EmailVerifiedAt      pgtype.Timestamptz `json:"email_verified_at"`
+	TotpSecret           pgtype.Text        `json:"totp_secret"`
+	TotpPendingSecret    pgtype.Text        `json:"totp_pending_secret"`
+	TotpEnabledAt        pgtype.Timestamptz `json:"totp_enabled_at"`
+	TotpLastStep         int64              `json:"totp_last_step"`
+	Role                 string             `json:"role"`
+	SuspendedAt          pgtype.Timestamptz `json:"suspended_at"`
+	SuspendedUntil       pgtype.Timestamptz `json:"suspended_until"`
+	SuspensionReason     string             `json:"suspension_reason"`
+	DeletionScheduledFor pgtype.Timestamptz `json:"deletion_scheduled_for"`
+	AnonymizedAt         pgtype.Timestamptz `json:"anonymized_at"`
+}
+
+type UserIdentity struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Provider    string    `json:"provider"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email"`
+	LastLoginAt time.Time `json:"last_login_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type UserSession struct {
+	ID         uuid.UUID `json:"id"`
+	Token      string    `json:"token"`
+	UserID     uuid.UUID `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IpAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type UserToken struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	Purpose   string             `json:"purpose"`
+	TokenHash []byte             `json:"token_hash"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type Watchlist struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WsTicket struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	TicketHash []byte    `json:"ticket_hash"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
 }
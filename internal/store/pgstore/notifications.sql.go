@@ -0,0 +1,136 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: notifications.sql
+
+package pgstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimPendingNotifications = `-- name: ClaimPendingNotifications :many
+UPDATE notifications
+SET attempts = attempts + 1, next_attempt_at = now() + $1::INTERVAL
+WHERE id IN (
+	SELECT id FROM notifications
+	WHERE status = 'pending' AND next_attempt_at <= now()
+	ORDER BY next_attempt_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED
+) RETURNING id, user_id, kind, payload, status, attempts, last_error, next_attempt_at, delivered_at, created_at, delivered_channels
+`
+
+type ClaimPendingNotificationsParams struct {
+	Lease     pgtype.Interval `json:"lease"`
+	BatchSize int32           `json:"batch_size"`
+}
+
+func (q *Queries) ClaimPendingNotifications(ctx context.Context, arg ClaimPendingNotificationsParams) ([]Notification, error) {
+	rows, err := q.db.Query(ctx, claimPendingNotifications, arg.Lease, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Kind,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+			&i.DeliveredChannels,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createNotification = `-- name: CreateNotification :exec
+INSERT INTO notifications (
+	user_id,
+	kind,
+	payload
+) VALUES (
+	$1,
+	$2,
+	$3
+)
+`
+
+type CreateNotificationParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Kind    string    `json:"kind"`
+	Payload []byte    `json:"payload"`
+}
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) error {
+	_, err := q.db.Exec(ctx, createNotification, arg.UserID, arg.Kind, arg.Payload)
+	return err
+}
+
+const markNotificationChannelDelivered = `-- name: MarkNotificationChannelDelivered :exec
+UPDATE notifications
+SET delivered_channels = array_append(delivered_channels, $1::TEXT)
+WHERE id = $2 AND NOT ($1::TEXT = ANY(delivered_channels))
+`
+
+type MarkNotificationChannelDeliveredParams struct {
+	Channel string    `json:"channel"`
+	ID      uuid.UUID `json:"id"`
+}
+
+func (q *Queries) MarkNotificationChannelDelivered(ctx context.Context, arg MarkNotificationChannelDeliveredParams) error {
+	_, err := q.db.Exec(ctx, markNotificationChannelDelivered, arg.Channel, arg.ID)
+	return err
+}
+
+const markNotificationDelivered = `-- name: MarkNotificationDelivered :exec
+UPDATE notifications
+SET status = 'delivered', delivered_at = now(), last_error = ''
+WHERE id = $1
+`
+
+func (q *Queries) MarkNotificationDelivered(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markNotificationDelivered, id)
+	return err
+}
+
+const markNotificationFailed = `-- name: MarkNotificationFailed :exec
+UPDATE notifications
+SET status = $1, last_error = $2, next_attempt_at = $3
+WHERE id = $4
+`
+
+type MarkNotificationFailedParams struct {
+	Status        string    `json:"status"`
+	LastError     string    `json:"last_error"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	ID            uuid.UUID `json:"id"`
+}
+
+func (q *Queries) MarkNotificationFailed(ctx context.Context, arg MarkNotificationFailedParams) error {
+	_, err := q.db.Exec(ctx, markNotificationFailed,
+		arg.Status,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.ID,
+	)
+	return err
+}
@@ -10,22 +10,24 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
 )
 
 const createProduct = `-- name: CreateProduct :one
 INSERT INTO products (
-	seller_id,product_name,description,baseprice, auction_end
+	seller_id,product_name,description,baseprice,currency, auction_end
 ) VALUES (
-	$1,$2,$3,$4,$5
+	$1,$2,$3,$4,$5,$6
 ) RETURNING id
 `
 
 type CreateProductParams struct {
-	SellerID    uuid.UUID `json:"seller_id"`
-	ProductName string    `json:"product_name"`
-	Description string    `json:"description"`
-	Baseprice   float64   `json:"baseprice"`
-	AuctionEnd  time.Time `json:"auction_end"`
+	SellerID    uuid.UUID      `json:"seller_id"`
+	ProductName string         `json:"product_name"`
+	Description string         `json:"description"`
+	Baseprice   money.Money    `json:"baseprice"`
+	Currency    money.Currency `json:"currency"`
+	AuctionEnd  time.Time      `json:"auction_end"`
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (uuid.UUID, error) {
@@ -34,6 +36,7 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (u
 		arg.ProductName,
 		arg.Description,
 		arg.Baseprice,
+		arg.Currency,
 		arg.AuctionEnd,
 	)
 	var id uuid.UUID
@@ -42,7 +45,7 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (u
 }
 
 const getProductById = `-- name: GetProductById :one
-SELECT id, seller_id, product_name, description, baseprice, auction_end, is_sold, created_at, updated_at FROM products
+SELECT id, seller_id, product_name, description, baseprice, auction_end, is_sold, created_at, updated_at, currency, closed_at, closed_by, close_reason FROM products
 WHERE id = $1
 `
 
@@ -59,6 +62,62 @@ func (q *Queries) GetProductById(ctx context.Context, id uuid.UUID) (Product, er
 		&i.IsSold,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
+		&i.ClosedAt,
+		&i.ClosedBy,
+		&i.CloseReason,
 	)
 	return i, err
 }
+
+const getProductByIdForUpdate = `-- name: GetProductByIdForUpdate :one
+SELECT id, seller_id, product_name, description, baseprice, auction_end, is_sold, created_at, updated_at, currency, closed_at, closed_by, close_reason FROM products
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetProductByIdForUpdate(ctx context.Context, id uuid.UUID) (Product, error) {
+	row := q.db.QueryRow(ctx, getProductByIdForUpdate, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.SellerID,
+		&i.ProductName,
+		&i.Description,
+		&i.Baseprice,
+		&i.AuctionEnd,
+		&i.IsSold,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Currency,
+		&i.ClosedAt,
+		&i.ClosedBy,
+		&i.CloseReason,
+	)
+	return i, err
+}
+
+const getSellerStats = `-- name: GetSellerStats :one
+SELECT
+	COUNT(*) FILTER (WHERE p.auction_end > now() AND NOT p.is_sold) AS active_listings,
+	COUNT(*) FILTER (
+		WHERE p.is_sold OR (
+			p.auction_end <= now() AND p.closed_at IS NULL
+			AND EXISTS (SELECT 1 FROM bids b WHERE b.product_id = p.id)
+		)
+	) AS completed_sales
+FROM products p
+WHERE p.seller_id = $1
+`
+
+type GetSellerStatsRow struct {
+	ActiveListings int64 `json:"active_listings"`
+	CompletedSales int64 `json:"completed_sales"`
+}
+
+func (q *Queries) GetSellerStats(ctx context.Context, sellerID uuid.UUID) (GetSellerStatsRow, error) {
+	row := q.db.QueryRow(ctx, getSellerStats, sellerID)
+	var i GetSellerStatsRow
+	err := row.Scan(&i.ActiveListings, &i.CompletedSales)
+	return i, err
+}
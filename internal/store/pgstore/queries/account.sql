@@ -0,0 +1,142 @@
+-- name: ListProductsBySeller :many
+SELECT * FROM products
+WHERE seller_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListBidsByUser :many
+SELECT
+	b.id,
+	b.product_id,
+	p.product_name,
+	p.currency,
+	b.bid_amount,
+	b.created_at
+FROM bids b
+JOIN products p ON p.id = b.product_id
+WHERE b.user_id = $1
+ORDER BY b.created_at DESC;
+
+-- name: ListUserIdentities :many
+SELECT * FROM user_identities
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: ListLoginAttemptsByUser :many
+SELECT * FROM login_attempts
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT 100;
+
+-- name: ListWatchlistByUser :many
+SELECT
+	w.product_id,
+	p.product_name,
+	w.created_at
+FROM watchlist w
+JOIN products p ON p.id = w.product_id
+WHERE w.user_id = $1
+ORDER BY w.created_at DESC;
+
+-- name: ListNotificationsByUser :many
+SELECT * FROM notifications
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListFeedbackByUser :many
+-- The feedback the user wrote.
+SELECT
+	f.id,
+	f.product_id,
+	p.product_name,
+	f.role,
+	f.to_user_id,
+	f.rating,
+	f.comment,
+	f.created_at
+FROM feedback f
+JOIN products p ON p.id = f.product_id
+WHERE f.from_user_id = $1
+ORDER BY f.created_at DESC;
+
+-- name: CountActiveAuctionsOfUser :one
+-- Auctions the user sells or currently leads, which would be left without a
+-- seller or a winner.
+SELECT COUNT(*) FROM products p
+WHERE p.auction_end > now()
+	AND (
+		p.seller_id = sqlc.arg(user_id)
+		OR sqlc.arg(user_id) = (
+			SELECT b.user_id FROM bids b
+			WHERE b.product_id = p.id
+			ORDER BY b.bid_amount DESC
+			LIMIT 1
+		)
+	);
+
+-- name: ScheduleAccountDeletion :exec
+UPDATE users
+SET deletion_scheduled_for = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: CancelAccountDeletion :exec
+UPDATE users
+SET deletion_scheduled_for = NULL, updated_at = now()
+WHERE id = $1;
+
+-- name: ClaimAccountsDueForDeletion :many
+SELECT id, email FROM users
+WHERE deletion_scheduled_for <= now() AND anonymized_at IS NULL
+ORDER BY deletion_scheduled_for
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: AnonymizeUser :exec
+UPDATE users
+SET
+	user_name = sqlc.arg(user_name),
+	email = sqlc.arg(email),
+	password_hash = '',
+	bio = '',
+	display_currency = NULL,
+	email_verified_at = NULL,
+	totp_secret = NULL,
+	totp_pending_secret = NULL,
+	totp_enabled_at = NULL,
+	role = 'user',
+	suspension_reason = '',
+	anonymized_at = now(),
+	updated_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: DeleteUserTokensOfUser :exec
+DELETE FROM user_tokens WHERE user_id = $1;
+
+-- name: DeleteRecoveryCodesOfUser :exec
+DELETE FROM recovery_codes WHERE user_id = $1;
+
+-- name: DeleteApiTokensOfUser :exec
+DELETE FROM api_tokens WHERE user_id = $1;
+
+-- name: DeleteRefreshTokensOfUser :exec
+DELETE FROM refresh_tokens WHERE user_id = $1;
+
+-- name: DeleteUserIdentitiesOfUser :exec
+DELETE FROM user_identities WHERE user_id = $1;
+
+-- name: DeleteWatchlistOfUser :exec
+DELETE FROM watchlist WHERE user_id = $1;
+
+-- name: DeleteNotificationsOfUser :exec
+DELETE FROM notifications WHERE user_id = $1;
+
+-- name: DeleteLoginAttemptsOfUser :exec
+DELETE FROM login_attempts WHERE user_id = sqlc.arg(user_id) OR email = sqlc.arg(email);
+
+-- name: DeleteWsTicketsOfUser :exec
+DELETE FROM ws_tickets WHERE user_id = $1;
+
+-- name: ClearFeedbackCommentsOfUser :exec
+UPDATE feedback SET comment = '' WHERE from_user_id = $1;
+
+-- name: DeleteCreditLimitsOfUser :exec
+DELETE FROM credit_limits WHERE user_id = $1;
@@ -0,0 +1,65 @@
+-- name: SearchUsers :many
+-- Users whose name or email contains the query, ignoring case. strpos takes
+-- the query as plain text, unlike a LIKE pattern where % and _ match
+-- anything.
+SELECT
+	id,
+	user_name,
+	email,
+	role,
+	email_verified_at,
+	suspended_at,
+	suspended_until,
+	suspension_reason,
+	created_at
+FROM users
+WHERE sqlc.arg(query)::TEXT = ''
+	OR strpos(lower(user_name), lower(sqlc.arg(query))) > 0
+	OR strpos(lower(email), lower(sqlc.arg(query))) > 0
+ORDER BY created_at DESC
+LIMIT sqlc.arg(max_results) OFFSET sqlc.arg(skip);
+
+-- name: UpdateUserRole :exec
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: SuspendUser :exec
+UPDATE users
+SET
+	suspended_at = now(),
+	suspended_until = sqlc.narg(suspended_until),
+	suspension_reason = sqlc.arg(suspension_reason),
+	updated_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: UnsuspendUser :exec
+UPDATE users
+SET
+	suspended_at = NULL,
+	suspended_until = NULL,
+	suspension_reason = '',
+	updated_at = now()
+WHERE id = $1;
+
+-- name: CloseAuction :execrows
+UPDATE products
+SET
+	auction_end = now(),
+	closed_at = now(),
+	closed_by = sqlc.arg(closed_by),
+	close_reason = sqlc.arg(close_reason),
+	updated_at = now()
+WHERE id = sqlc.arg(id) AND auction_end > now();
+
+-- name: GetBidHistory :many
+SELECT
+	b.id,
+	b.user_id,
+	u.user_name,
+	b.bid_amount,
+	b.created_at
+FROM bids b
+JOIN users u ON u.id = b.user_id
+WHERE b.product_id = $1
+ORDER BY b.created_at DESC;
@@ -0,0 +1,32 @@
+-- name: CreateApiToken :one
+INSERT INTO api_tokens (
+	user_id,
+	name,
+	token_hash,
+	scopes,
+	expires_at
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5
+) RETURNING *;
+
+-- name: GetApiTokenByHash :one
+SELECT * FROM api_tokens
+WHERE token_hash = $1 AND (expires_at IS NULL OR expires_at > now());
+
+-- name: TouchApiToken :exec
+UPDATE api_tokens SET last_used_at = now() WHERE id = $1;
+
+-- name: ListApiTokens :many
+SELECT * FROM api_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: CountApiTokens :one
+SELECT COUNT(*) FROM api_tokens WHERE user_id = $1;
+
+-- name: DeleteApiToken :execrows
+DELETE FROM api_tokens WHERE id = $1 AND user_id = $2;
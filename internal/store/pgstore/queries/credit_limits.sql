@@ -0,0 +1,43 @@
+-- name: GetCreditLimitForUpdate :one
+-- Locking the limit serializes the bids of the user in the currency, so
+-- they can't go over it together.
+SELECT amount FROM credit_limits
+WHERE user_id = $1 AND currency = $2
+FOR UPDATE;
+
+-- name: GetCreditExposure :one
+-- The sum of the highest bids of the user on the auctions they lead or won
+-- in the currency, leaving out one product. Won auctions stay in until
+-- payments are settled, which they aren't yet, so winning doesn't free credit.
+SELECT COALESCE(SUM(hb.bid_amount), 0)::BIGINT AS exposure
+FROM products p
+JOIN LATERAL (
+	SELECT b.user_id, b.bid_amount FROM bids b
+	WHERE b.product_id = p.id
+	ORDER BY b.bid_amount DESC
+	LIMIT 1
+) hb ON TRUE
+WHERE p.currency = sqlc.arg(currency)
+	AND p.closed_at IS NULL
+	AND p.id <> sqlc.arg(excluded_product_id)
+	AND hb.user_id = sqlc.arg(user_id)::UUID;
+
+-- name: ListCreditLimits :many
+SELECT * FROM credit_limits
+WHERE user_id = $1
+ORDER BY currency;
+
+-- name: SetCreditLimit :exec
+INSERT INTO credit_limits (
+	user_id,
+	currency,
+	amount
+) VALUES (
+	$1,
+	$2,
+	$3
+) ON CONFLICT (user_id, currency) DO UPDATE
+SET amount = EXCLUDED.amount, updated_at = now();
+
+-- name: DeleteCreditLimit :exec
+DELETE FROM credit_limits WHERE user_id = $1 AND currency = $2;
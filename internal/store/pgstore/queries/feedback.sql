@@ -0,0 +1,47 @@
+-- name: CreateFeedback :execrows
+INSERT INTO feedback (
+	product_id,
+	role,
+	from_user_id,
+	to_user_id,
+	rating,
+	comment
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6
+) ON CONFLICT (product_id, role) DO NOTHING;
+
+-- name: ListFeedbackForUser :many
+SELECT
+	f.id,
+	f.product_id,
+	p.product_name,
+	f.role,
+	f.from_user_id,
+	u.user_name AS from_user_name,
+	f.rating,
+	f.comment,
+	f.created_at
+FROM feedback f
+JOIN products p ON p.id = f.product_id
+JOIN users u ON u.id = f.from_user_id
+WHERE f.to_user_id = $1
+ORDER BY f.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: GetReputation :one
+-- Ratings received, overall and split by the part the user played: the
+-- ones left by buyers rate the user as a seller.
+SELECT
+	COUNT(*) AS ratings,
+	COALESCE(AVG(rating), 0)::float8 AS average,
+	COUNT(*) FILTER (WHERE role = 'buyer') AS seller_ratings,
+	COALESCE(AVG(rating) FILTER (WHERE role = 'buyer'), 0)::float8 AS seller_average,
+	COUNT(*) FILTER (WHERE role = 'seller') AS buyer_ratings,
+	COALESCE(AVG(rating) FILTER (WHERE role = 'seller'), 0)::float8 AS buyer_average
+FROM feedback
+WHERE to_user_id = $1;
@@ -0,0 +1,114 @@
+-- name: ListSellerListings :many
+-- Products closed by a moderator are "closed", the others are "live" until
+-- their end and then "sold" or "unsold" depending on whether they got bids.
+-- Filtering on "ended" returns every finished product, whatever its status.
+SELECT * FROM (
+	SELECT
+		p.id,
+		p.product_name,
+		p.currency,
+		p.auction_end,
+		p.created_at,
+		COALESCE(hb.bid_amount, p.baseprice)::BIGINT AS current_price,
+		COALESCE(bs.bid_count, 0)::BIGINT AS bid_count,
+		COALESCE(bs.unique_bidders, 0)::BIGINT AS unique_bidders,
+		(SELECT COUNT(*) FROM watchlist w WHERE w.product_id = p.id)::BIGINT AS watchers,
+		(CASE
+			WHEN p.closed_at IS NOT NULL THEN 'closed'
+			WHEN p.auction_end > now() THEN 'live'
+			WHEN p.is_sold OR hb.bid_amount IS NOT NULL THEN 'sold'
+			ELSE 'unsold'
+		END)::TEXT AS status
+	FROM products p
+	LEFT JOIN LATERAL (
+		SELECT b.bid_amount FROM bids b
+		WHERE b.product_id = p.id
+		ORDER BY b.bid_amount DESC
+		LIMIT 1
+	) hb ON TRUE
+	LEFT JOIN LATERAL (
+		SELECT COUNT(*) AS bid_count, COUNT(DISTINCT b.user_id) AS unique_bidders
+		FROM bids b
+		WHERE b.product_id = p.id
+	) bs ON TRUE
+	WHERE p.seller_id = sqlc.arg(seller_id)
+) l
+WHERE sqlc.arg(status)::TEXT = '' OR l.status = sqlc.arg(status)
+	OR (sqlc.arg(status) = 'ended' AND l.status <> 'live')
+ORDER BY l.auction_end DESC
+LIMIT sqlc.arg(max_results) OFFSET sqlc.arg(skip);
+
+-- name: CountSellerListingsByStatus :one
+SELECT
+	COUNT(*) FILTER (WHERE p.closed_at IS NULL AND p.auction_end > now()) AS live,
+	COUNT(*) FILTER (
+		WHERE p.closed_at IS NULL AND p.auction_end <= now() AND (
+			p.is_sold OR EXISTS (SELECT 1 FROM bids b WHERE b.product_id = p.id)
+		)
+	) AS sold,
+	COUNT(*) FILTER (
+		WHERE p.closed_at IS NULL AND p.auction_end <= now() AND NOT p.is_sold
+			AND NOT EXISTS (SELECT 1 FROM bids b WHERE b.product_id = p.id)
+	) AS unsold,
+	COUNT(*) FILTER (WHERE p.closed_at IS NOT NULL) AS closed
+FROM products p
+WHERE p.seller_id = $1;
+
+-- name: GetSellerRevenue :many
+-- Revenue of the sold products, the winning bids, by currency.
+SELECT
+	p.currency,
+	SUM(hb.bid_amount)::BIGINT AS revenue
+FROM products p
+JOIN LATERAL (
+	SELECT b.bid_amount FROM bids b
+	WHERE b.product_id = p.id
+	ORDER BY b.bid_amount DESC
+	LIMIT 1
+) hb ON TRUE
+WHERE p.seller_id = $1 AND p.closed_at IS NULL AND p.auction_end <= now()
+GROUP BY p.currency
+ORDER BY p.currency;
+
+-- name: ListBidderActivity :many
+-- The products a user bid on. Auctions closed by a moderator are "closed",
+-- the others are "winning" or "outbid" until their end and then "won" or
+-- "lost".
+SELECT * FROM (
+	SELECT
+		p.id,
+		p.product_name,
+		p.currency,
+		p.auction_end,
+		mb.max_bid::BIGINT AS my_max_bid,
+		mb.bid_count::BIGINT AS my_bids,
+		mb.last_bid_at::TIMESTAMPTZ AS last_bid_at,
+		hb.bid_amount::BIGINT AS current_price,
+		(CASE
+			WHEN p.closed_at IS NOT NULL THEN 'closed'
+			WHEN p.auction_end > now() AND hb.user_id = sqlc.arg(user_id)::UUID THEN 'winning'
+			WHEN p.auction_end > now() THEN 'outbid'
+			WHEN hb.user_id = sqlc.arg(user_id)::UUID THEN 'won'
+			ELSE 'lost'
+		END)::TEXT AS state
+	FROM products p
+	JOIN (
+		SELECT
+			b.product_id,
+			MAX(b.bid_amount) AS max_bid,
+			COUNT(*) AS bid_count,
+			MAX(b.created_at) AS last_bid_at
+		FROM bids b
+		WHERE b.user_id = sqlc.arg(user_id)
+		GROUP BY b.product_id
+	) mb ON mb.product_id = p.id
+	JOIN LATERAL (
+		SELECT b.user_id, b.bid_amount FROM bids b
+		WHERE b.product_id = p.id
+		ORDER BY b.bid_amount DESC
+		LIMIT 1
+	) hb ON TRUE
+) a
+WHERE sqlc.arg(state)::TEXT = '' OR a.state = sqlc.arg(state)
+ORDER BY a.auction_end DESC
+LIMIT sqlc.arg(max_results) OFFSET sqlc.arg(skip);
@@ -0,0 +1,46 @@
+-- name: RecordLoginAttempt :exec
+INSERT INTO login_attempts (
+	email,
+	user_id,
+	ip_address,
+	outcome
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+);
+
+-- name: GetEmailLoginFailures :one
+-- Failures since the last successful login, within the last day.
+SELECT
+	COUNT(*) AS failures,
+	COALESCE(MAX(a.created_at), 'epoch')::TIMESTAMPTZ AS last_failure
+FROM login_attempts a
+WHERE a.email = $1
+	AND a.outcome = 'failure'
+	AND a.created_at > now() - INTERVAL '1 day'
+	AND a.created_at > COALESCE((
+		SELECT MAX(s.created_at) FROM login_attempts s
+		WHERE s.email = $1 AND s.outcome = 'success'
+	), 'epoch');
+
+-- name: GetIPLoginFailures :one
+-- Failures within the last hour. Successful logins don't reset them, or an
+-- attacker could clear the count by logging into an account of their own.
+SELECT
+	COUNT(*) AS failures,
+	COALESCE(MAX(a.created_at), 'epoch')::TIMESTAMPTZ AS last_failure
+FROM login_attempts a
+WHERE a.ip_address = $1
+	AND a.outcome = 'failure'
+	AND a.created_at > now() - INTERVAL '1 hour';
+
+-- name: LockLoginEmail :exec
+-- Held until the end of the transaction, so attempts for the same email are
+-- checked and recorded one after the other.
+SELECT pg_advisory_xact_lock(hashtextextended('login_email:' || sqlc.arg(email)::TEXT, 0));
+
+-- name: LockLoginIP :exec
+-- The same for attempts from the same IP address.
+SELECT pg_advisory_xact_lock(hashtextextended('login_ip:' || sqlc.arg(ip_address)::TEXT, 0));
@@ -0,0 +1,36 @@
+-- name: CreateNotification :exec
+INSERT INTO notifications (
+	user_id,
+	kind,
+	payload
+) VALUES (
+	$1,
+	$2,
+	$3
+);
+
+-- name: ClaimPendingNotifications :many
+UPDATE notifications
+SET attempts = attempts + 1, next_attempt_at = now() + sqlc.arg(lease)::INTERVAL
+WHERE id IN (
+	SELECT id FROM notifications
+	WHERE status = 'pending' AND next_attempt_at <= now()
+	ORDER BY next_attempt_at
+	LIMIT sqlc.arg(batch_size)
+	FOR UPDATE SKIP LOCKED
+) RETURNING *;
+
+-- name: MarkNotificationDelivered :exec
+UPDATE notifications
+SET status = 'delivered', delivered_at = now(), last_error = ''
+WHERE id = $1;
+
+-- name: MarkNotificationFailed :exec
+UPDATE notifications
+SET status = sqlc.arg(status), last_error = sqlc.arg(last_error), next_attempt_at = sqlc.arg(next_attempt_at)
+WHERE id = sqlc.arg(id);
+
+-- name: MarkNotificationChannelDelivered :exec
+UPDATE notifications
+SET delivered_channels = array_append(delivered_channels, sqlc.arg(channel)::TEXT)
+WHERE id = sqlc.arg(id) AND NOT (sqlc.arg(channel)::TEXT = ANY(delivered_channels));
@@ -1,10 +1,27 @@
 -- name: CreateProduct :one
 INSERT INTO products (
-	seller_id,product_name,description,baseprice, auction_end
+	seller_id,product_name,description,baseprice,currency, auction_end
 ) VALUES (
-	$1,$2,$3,$4,$5
+	$1,$2,$3,$4,$5,$6
 ) RETURNING id;
 
 -- name: GetProductById :one
 SELECT * FROM products
 WHERE id = $1;
+
+-- name: GetProductByIdForUpdate :one
+SELECT * FROM products
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetSellerStats :one
+SELECT
+	COUNT(*) FILTER (WHERE p.auction_end > now() AND NOT p.is_sold) AS active_listings,
+	COUNT(*) FILTER (
+		WHERE p.is_sold OR (
+			p.auction_end <= now() AND p.closed_at IS NULL
+			AND EXISTS (SELECT 1 FROM bids b WHERE b.product_id = p.id)
+		)
+	) AS completed_sales
+FROM products p
+WHERE p.seller_id = $1;
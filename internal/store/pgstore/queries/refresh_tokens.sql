@@ -0,0 +1,30 @@
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (
+	user_id,
+	family_id,
+	token_hash,
+	expires_at
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+);
+
+-- name: GetRefreshTokenByHashForUpdate :one
+SELECT * FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE;
+
+-- name: UseRefreshToken :exec
+UPDATE refresh_tokens SET used_at = now() WHERE id = $1;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens SET revoked_at = now()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteStaleRefreshTokens :exec
+DELETE FROM refresh_tokens
+WHERE user_id = $1 AND expires_at < now() - INTERVAL '30 days';
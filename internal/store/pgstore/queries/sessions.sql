@@ -0,0 +1,2 @@
+-- name: DeleteSessions :exec
+DELETE FROM sessions WHERE token = ANY(sqlc.arg(tokens)::TEXT[]);
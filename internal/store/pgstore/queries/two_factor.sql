@@ -0,0 +1,46 @@
+-- name: SetPendingTotpSecret :exec
+UPDATE users
+SET totp_pending_secret = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: EnableTotp :exec
+UPDATE users
+SET
+	totp_secret = totp_pending_secret,
+	totp_pending_secret = NULL,
+	totp_enabled_at = now(),
+	totp_last_step = sqlc.arg(last_step),
+	updated_at = now()
+WHERE id = sqlc.arg(id) AND totp_pending_secret IS NOT NULL;
+
+-- name: DisableTotp :exec
+UPDATE users
+SET
+	totp_secret = NULL,
+	totp_pending_secret = NULL,
+	totp_enabled_at = NULL,
+	totp_last_step = 0,
+	updated_at = now()
+WHERE id = $1;
+
+-- name: UseTotpStep :execrows
+UPDATE users
+SET totp_last_step = sqlc.arg(step)
+WHERE id = sqlc.arg(id) AND totp_last_step < sqlc.arg(step);
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (
+	user_id,
+	code_hash
+) VALUES (
+	$1,
+	$2
+);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
@@ -8,3 +8,27 @@ SELECT * FROM users WHERE id = $1;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users WHERE email = $1;
+
+-- name: UpdateUserDisplayCurrency :exec
+UPDATE users
+SET display_currency = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET
+	user_name = COALESCE(sqlc.narg(user_name), user_name),
+	bio = COALESCE(sqlc.narg(bio), bio),
+	updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: MarkEmailVerified :exec
+UPDATE users
+SET email_verified_at = now(), updated_at = now()
+WHERE id = $1 AND email_verified_at IS NULL;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2, updated_at = now()
+WHERE id = $1;
@@ -0,0 +1,38 @@
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities WHERE provider = $1 AND subject = $2;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (
+	user_id,
+	provider,
+	subject,
+	email
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+);
+
+-- name: TouchUserIdentity :exec
+UPDATE user_identities
+SET email = $2, last_login_at = now()
+WHERE id = $1;
+
+-- name: CreateIdentityUser :one
+-- Users signing up through a provider have a verified email already. Nothing
+-- is returned when the email or the user name are taken.
+INSERT INTO users (
+	user_name,
+	email,
+	password_hash,
+	bio,
+	email_verified_at
+) VALUES (
+	$1,
+	$2,
+	$3,
+	'',
+	now()
+) ON CONFLICT DO NOTHING
+RETURNING id;
@@ -0,0 +1,45 @@
+-- name: CreateUserSession :exec
+INSERT INTO user_sessions (
+	token,
+	user_id,
+	user_agent,
+	ip_address
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+) ON CONFLICT (token) DO NOTHING;
+
+-- name: GetUserSessionByToken :one
+SELECT * FROM user_sessions WHERE token = $1;
+
+-- name: TouchUserSession :exec
+UPDATE user_sessions SET last_seen_at = now() WHERE id = $1;
+
+-- name: ListUserSessions :many
+SELECT us.* FROM user_sessions us
+JOIN sessions s ON s.token = us.token
+WHERE us.user_id = $1 AND s.expiry > now()
+ORDER BY us.last_seen_at DESC;
+
+-- name: DeleteUserSession :one
+DELETE FROM user_sessions WHERE id = $1 AND user_id = $2
+RETURNING token;
+
+-- name: DeleteUserSessionByToken :exec
+DELETE FROM user_sessions WHERE token = $1;
+
+-- name: DeleteOtherUserSessions :many
+DELETE FROM user_sessions WHERE user_id = $1 AND token <> sqlc.arg(keep_token)
+RETURNING token;
+
+-- name: PruneUserSessions :exec
+-- Sessions are only written to the sessions table at the end of the request
+-- that created them, so recent rows are kept.
+DELETE FROM user_sessions us
+WHERE us.user_id = $1
+	AND us.created_at < now() - INTERVAL '1 minute'
+	AND NOT EXISTS (
+		SELECT 1 FROM sessions s WHERE s.token = us.token AND s.expiry > now()
+	);
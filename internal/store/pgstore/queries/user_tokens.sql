@@ -0,0 +1,26 @@
+-- name: CreateUserToken :exec
+INSERT INTO user_tokens (
+	user_id,
+	purpose,
+	token_hash,
+	expires_at
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+);
+
+-- name: ConsumeUserToken :one
+UPDATE user_tokens
+SET used_at = now()
+WHERE token_hash = $1
+	AND purpose = $2
+	AND used_at IS NULL
+	AND expires_at > now()
+RETURNING user_id;
+
+-- name: ExpireUserTokens :exec
+UPDATE user_tokens
+SET used_at = now()
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL;
@@ -0,0 +1,32 @@
+-- name: WatchProduct :exec
+INSERT INTO watchlist (
+	user_id,
+	product_id
+) VALUES (
+	$1,
+	$2
+) ON CONFLICT DO NOTHING;
+
+-- name: UnwatchProduct :exec
+DELETE FROM watchlist WHERE user_id = $1 AND product_id = $2;
+
+-- name: ListWatchedProducts :many
+SELECT
+	p.id,
+	p.product_name,
+	p.currency,
+	p.auction_end,
+	p.is_sold,
+	COALESCE(hb.bid_amount, p.baseprice)::BIGINT AS current_price,
+	COALESCE(hb.user_id = w.user_id, FALSE)::BOOLEAN AS is_high_bidder,
+	w.created_at AS watched_at
+FROM watchlist w
+JOIN products p ON p.id = w.product_id
+LEFT JOIN LATERAL (
+	SELECT b.user_id, b.bid_amount FROM bids b
+	WHERE b.product_id = p.id
+	ORDER BY b.bid_amount DESC
+	LIMIT 1
+) hb ON TRUE
+WHERE w.user_id = $1
+ORDER BY p.auction_end;
@@ -0,0 +1,19 @@
+-- name: CreateWsTicket :exec
+INSERT INTO ws_tickets (
+	user_id,
+	ticket_hash,
+	expires_at
+) VALUES (
+	$1,
+	$2,
+	$3
+);
+
+-- name: ConsumeWsTicket :one
+-- Deleting the ticket as it is read makes it single use.
+DELETE FROM ws_tickets
+WHERE ticket_hash = $1 AND expires_at > now()
+RETURNING user_id;
+
+-- name: DeleteExpiredWsTickets :exec
+DELETE FROM ws_tickets WHERE expires_at <= now();
@@ -0,0 +1,19 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: sessions.sql
+
+package pgstore
+
+import (
+	"context"
+)
+
+const deleteSessions = `-- name: DeleteSessions :exec
+DELETE FROM sessions WHERE token = ANY($1::TEXT[])
+`
+
+func (q *Queries) DeleteSessions(ctx context.Context, tokens []string) error {
+	_, err := q.db.Exec(ctx, deleteSessions, tokens)
+	return err
+}
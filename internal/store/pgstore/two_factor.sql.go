@@ -0,0 +1,133 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: two_factor.sql
+
+package pgstore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (
+	user_id,
+	code_hash
+) VALUES (
+	$1,
+	$2
+)
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	CodeHash []byte    `json:"code_hash"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.Exec(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
+const disableTotp = `-- name: DisableTotp :exec
+UPDATE users
+SET
+	totp_secret = NULL,
+	totp_pending_secret = NULL,
+	totp_enabled_at = NULL,
+	totp_last_step = 0,
+	updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) DisableTotp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, disableTotp, id)
+	return err
+}
+
+const enableTotp = `-- name: EnableTotp :exec
+UPDATE users
+SET
+	totp_secret = totp_pending_secret,
+	totp_pending_secret = NULL,
+	totp_enabled_at = now(),
+	totp_last_step = $1,
+	updated_at = now()
+WHERE id = $2 AND totp_pending_secret IS NOT NULL
+`
+
+type EnableTotpParams struct {
+	LastStep int64     `json:"last_step"`
+	ID       uuid.UUID `json:"id"`
+}
+
+func (q *Queries) EnableTotp(ctx context.Context, arg EnableTotpParams) error {
+	_, err := q.db.Exec(ctx, enableTotp, arg.LastStep, arg.ID)
+	return err
+}
+
+const setPendingTotpSecret = `-- name: SetPendingTotpSecret :exec
+UPDATE users
+SET totp_pending_secret = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetPendingTotpSecretParams struct {
+	ID                uuid.UUID   `json:"id"`
+	TotpPendingSecret pgtype.Text `json:"totp_pending_secret"`
+}
+
+func (q *Queries) SetPendingTotpSecret(ctx context.Context, arg SetPendingTotpSecretParams) error {
+	_, err := q.db.Exec(ctx, setPendingTotpSecret, arg.ID, arg.TotpPendingSecret)
+	return err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	CodeHash []byte    `json:"code_hash"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const useTotpStep = `-- name: UseTotpStep :execrows
+UPDATE users
+SET totp_last_step = $1
+WHERE id = $2 AND totp_last_step < $1
+`
+
+type UseTotpStepParams struct {
+	Step int64     `json:"step"`
+	ID   uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UseTotpStep(ctx context.Context, arg UseTotpStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useTotpStep, arg.Step, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
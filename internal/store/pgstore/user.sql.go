@@ -9,6 +9,8 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -36,7 +38,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (uuid.UU
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, user_name, email, password_hash, bio, created_at, updated_at FROM users WHERE email = $1
+SELECT id, user_name, email, password_hash, bio, created_at, updated_at, display_currency, email_verified_at, totp_secret, totp_pending_secret, totp_enabled_at, totp_last_step, role, suspended_at, suspended_until, suspension_reason, deletion_scheduled_for, anonymized_at FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -50,12 +52,24 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Bio,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DisplayCurrency,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpPendingSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.DeletionScheduledFor,
+		&i.AnonymizedAt,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, user_name, email, password_hash, bio, created_at, updated_at FROM users WHERE id = $1
+SELECT id, user_name, email, password_hash, bio, created_at, updated_at, display_currency, email_verified_at, totp_secret, totp_pending_secret, totp_enabled_at, totp_last_step, role, suspended_at, suspended_until, suspension_reason, deletion_scheduled_for, anonymized_at FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
@@ -69,6 +83,104 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Bio,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DisplayCurrency,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpPendingSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.DeletionScheduledFor,
+		&i.AnonymizedAt,
+	)
+	return i, err
+}
+
+const markEmailVerified = `-- name: MarkEmailVerified :exec
+UPDATE users
+SET email_verified_at = now(), updated_at = now()
+WHERE id = $1 AND email_verified_at IS NULL
+`
+
+func (q *Queries) MarkEmailVerified(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markEmailVerified, id)
+	return err
+}
+
+const updateUserDisplayCurrency = `-- name: UpdateUserDisplayCurrency :exec
+UPDATE users
+SET display_currency = $2, updated_at = now()
+WHERE id = $1
+`
+
+type UpdateUserDisplayCurrencyParams struct {
+	ID              uuid.UUID       `json:"id"`
+	DisplayCurrency *money.Currency `json:"display_currency"`
+}
+
+func (q *Queries) UpdateUserDisplayCurrency(ctx context.Context, arg UpdateUserDisplayCurrencyParams) error {
+	_, err := q.db.Exec(ctx, updateUserDisplayCurrency, arg.ID, arg.DisplayCurrency)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2, updated_at = now()
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID           uuid.UUID `json:"id"`
+	PasswordHash []byte    `json:"password_hash"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET
+	user_name = COALESCE($1, user_name),
+	bio = COALESCE($2, bio),
+	updated_at = now()
+WHERE id = $3
+RETURNING id, user_name, email, password_hash, bio, created_at, updated_at, display_currency, email_verified_at, totp_secret, totp_pending_secret, totp_enabled_at, totp_last_step, role, suspended_at, suspended_until, suspension_reason, deletion_scheduled_for, anonymized_at
+`
+
+type UpdateUserProfileParams struct {
+	UserName pgtype.Text `json:"user_name"`
+	Bio      pgtype.Text `json:"bio"`
+	ID       uuid.UUID   `json:"id"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserProfile, arg.UserName, arg.Bio, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.UserName,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Bio,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisplayCurrency,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpPendingSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.DeletionScheduledFor,
+		&i.AnonymizedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,115 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: user_identities.sql
+
+package pgstore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createIdentityUser = `-- name: CreateIdentityUser :one
+INSERT INTO users (
+	user_name,
+	email,
+	password_hash,
+	bio,
+	email_verified_at
+) VALUES (
+	$1,
+	$2,
+	$3,
+	'',
+	now()
+) ON CONFLICT DO NOTHING
+RETURNING id
+`
+
+type CreateIdentityUserParams struct {
+	UserName     string `json:"user_name"`
+	Email        string `json:"email"`
+	PasswordHash []byte `json:"password_hash"`
+}
+
+// Users signing up through a provider have a verified email already. Nothing
+// is returned when the email or the user name are taken.
+func (q *Queries) CreateIdentityUser(ctx context.Context, arg CreateIdentityUserParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createIdentityUser, arg.UserName, arg.Email, arg.PasswordHash)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createUserIdentity = `-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (
+	user_id,
+	provider,
+	subject,
+	email
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+)
+`
+
+type CreateUserIdentityParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Provider string    `json:"provider"`
+	Subject  string    `json:"subject"`
+	Email    string    `json:"email"`
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error {
+	_, err := q.db.Exec(ctx, createUserIdentity,
+		arg.UserID,
+		arg.Provider,
+		arg.Subject,
+		arg.Email,
+	)
+	return err
+}
+
+const getUserIdentity = `-- name: GetUserIdentity :one
+SELECT id, user_id, provider, subject, email, last_login_at, created_at FROM user_identities WHERE provider = $1 AND subject = $2
+`
+
+type GetUserIdentityParams struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+}
+
+func (q *Queries) GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error) {
+	row := q.db.QueryRow(ctx, getUserIdentity, arg.Provider, arg.Subject)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Provider,
+		&i.Subject,
+		&i.Email,
+		&i.LastLoginAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const touchUserIdentity = `-- name: TouchUserIdentity :exec
+UPDATE user_identities
+SET email = $2, last_login_at = now()
+WHERE id = $1
+`
+
+type TouchUserIdentityParams struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+func (q *Queries) TouchUserIdentity(ctx context.Context, arg TouchUserIdentityParams) error {
+	_, err := q.db.Exec(ctx, touchUserIdentity, arg.ID, arg.Email)
+	return err
+}
@@ -0,0 +1,178 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: user_sessions.sql
+
+package pgstore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createUserSession = `-- name: CreateUserSession :exec
+INSERT INTO user_sessions (
+	token,
+	user_id,
+	user_agent,
+	ip_address
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+) ON CONFLICT (token) DO NOTHING
+`
+
+type CreateUserSessionParams struct {
+	Token     string    `json:"token"`
+	UserID    uuid.UUID `json:"user_id"`
+	UserAgent string    `json:"user_agent"`
+	IpAddress string    `json:"ip_address"`
+}
+
+func (q *Queries) CreateUserSession(ctx context.Context, arg CreateUserSessionParams) error {
+	_, err := q.db.Exec(ctx, createUserSession,
+		arg.Token,
+		arg.UserID,
+		arg.UserAgent,
+		arg.IpAddress,
+	)
+	return err
+}
+
+const deleteOtherUserSessions = `-- name: DeleteOtherUserSessions :many
+DELETE FROM user_sessions WHERE user_id = $1 AND token <> $2
+RETURNING token
+`
+
+type DeleteOtherUserSessionsParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	KeepToken string    `json:"keep_token"`
+}
+
+func (q *Queries) DeleteOtherUserSessions(ctx context.Context, arg DeleteOtherUserSessionsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, deleteOtherUserSessions, arg.UserID, arg.KeepToken)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return nil, err
+		}
+		items = append(items, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteUserSession = `-- name: DeleteUserSession :one
+DELETE FROM user_sessions WHERE id = $1 AND user_id = $2
+RETURNING token
+`
+
+type DeleteUserSessionParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (string, error) {
+	row := q.db.QueryRow(ctx, deleteUserSession, arg.ID, arg.UserID)
+	var token string
+	err := row.Scan(&token)
+	return token, err
+}
+
+const deleteUserSessionByToken = `-- name: DeleteUserSessionByToken :exec
+DELETE FROM user_sessions WHERE token = $1
+`
+
+func (q *Queries) DeleteUserSessionByToken(ctx context.Context, token string) error {
+	_, err := q.db.Exec(ctx, deleteUserSessionByToken, token)
+	return err
+}
+
+const getUserSessionByToken = `-- name: GetUserSessionByToken :one
+SELECT id, token, user_id, user_agent, ip_address, created_at, last_seen_at FROM user_sessions WHERE token = $1
+`
+
+func (q *Queries) GetUserSessionByToken(ctx context.Context, token string) (UserSession, error) {
+	row := q.db.QueryRow(ctx, getUserSessionByToken, token)
+	var i UserSession
+	err := row.Scan(
+		&i.ID,
+		&i.Token,
+		&i.UserID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.CreatedAt,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT us.id, us.token, us.user_id, us.user_agent, us.ip_address, us.created_at, us.last_seen_at FROM user_sessions us
+JOIN sessions s ON s.token = us.token
+WHERE us.user_id = $1 AND s.expiry > now()
+ORDER BY us.last_seen_at DESC
+`
+
+func (q *Queries) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]UserSession, error) {
+	rows, err := q.db.Query(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserSession
+	for rows.Next() {
+		var i UserSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.Token,
+			&i.UserID,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pruneUserSessions = `-- name: PruneUserSessions :exec
+DELETE FROM user_sessions us
+WHERE us.user_id = $1
+	AND us.created_at < now() - INTERVAL '1 minute'
+	AND NOT EXISTS (
+		SELECT 1 FROM sessions s WHERE s.token = us.token AND s.expiry > now()
+	)
+`
+
+// Sessions are only written to the sessions table at the end of the request
+// that created them, so recent rows are kept.
+func (q *Queries) PruneUserSessions(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, pruneUserSessions, userID)
+	return err
+}
+
+const touchUserSession = `-- name: TouchUserSession :exec
+UPDATE user_sessions SET last_seen_at = now() WHERE id = $1
+`
+
+func (q *Queries) TouchUserSession(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, touchUserSession, id)
+	return err
+}
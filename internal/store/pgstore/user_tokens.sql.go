@@ -0,0 +1,82 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: user_tokens.sql
+
+package pgstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const consumeUserToken = `-- name: ConsumeUserToken :one
+UPDATE user_tokens
+SET used_at = now()
+WHERE token_hash = $1
+	AND purpose = $2
+	AND used_at IS NULL
+	AND expires_at > now()
+RETURNING user_id
+`
+
+type ConsumeUserTokenParams struct {
+	TokenHash []byte `json:"token_hash"`
+	Purpose   string `json:"purpose"`
+}
+
+func (q *Queries) ConsumeUserToken(ctx context.Context, arg ConsumeUserTokenParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, consumeUserToken, arg.TokenHash, arg.Purpose)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const createUserToken = `-- name: CreateUserToken :exec
+INSERT INTO user_tokens (
+	user_id,
+	purpose,
+	token_hash,
+	expires_at
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+)
+`
+
+type CreateUserTokenParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Purpose   string    `json:"purpose"`
+	TokenHash []byte    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateUserToken(ctx context.Context, arg CreateUserTokenParams) error {
+	_, err := q.db.Exec(ctx, createUserToken,
+		arg.UserID,
+		arg.Purpose,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const expireUserTokens = `-- name: ExpireUserTokens :exec
+UPDATE user_tokens
+SET used_at = now()
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+`
+
+type ExpireUserTokensParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Purpose string    `json:"purpose"`
+}
+
+func (q *Queries) ExpireUserTokens(ctx context.Context, arg ExpireUserTokensParams) error {
+	_, err := q.db.Exec(ctx, expireUserTokens, arg.UserID, arg.Purpose)
+	return err
+}
@@ -0,0 +1,110 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: watchlist.sql
+
+package pgstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+)
+
+const listWatchedProducts = `-- name: ListWatchedProducts :many
+SELECT
+	p.id,
+	p.product_name,
+	p.currency,
+	p.auction_end,
+	p.is_sold,
+	COALESCE(hb.bid_amount, p.baseprice)::BIGINT AS current_price,
+	COALESCE(hb.user_id = w.user_id, FALSE)::BOOLEAN AS is_high_bidder,
+	w.created_at AS watched_at
+FROM watchlist w
+JOIN products p ON p.id = w.product_id
+LEFT JOIN LATERAL (
+	SELECT b.user_id, b.bid_amount FROM bids b
+	WHERE b.product_id = p.id
+	ORDER BY b.bid_amount DESC
+	LIMIT 1
+) hb ON TRUE
+WHERE w.user_id = $1
+ORDER BY p.auction_end
+`
+
+type ListWatchedProductsRow struct {
+	ID           uuid.UUID      `json:"id"`
+	ProductName  string         `json:"product_name"`
+	Currency     money.Currency `json:"currency"`
+	AuctionEnd   time.Time      `json:"auction_end"`
+	IsSold       bool           `json:"is_sold"`
+	CurrentPrice int64          `json:"current_price"`
+	IsHighBidder bool           `json:"is_high_bidder"`
+	WatchedAt    time.Time      `json:"watched_at"`
+}
+
+func (q *Queries) ListWatchedProducts(ctx context.Context, userID uuid.UUID) ([]ListWatchedProductsRow, error) {
+	rows, err := q.db.Query(ctx, listWatchedProducts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWatchedProductsRow
+	for rows.Next() {
+		var i ListWatchedProductsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductName,
+			&i.Currency,
+			&i.AuctionEnd,
+			&i.IsSold,
+			&i.CurrentPrice,
+			&i.IsHighBidder,
+			&i.WatchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unwatchProduct = `-- name: UnwatchProduct :exec
+DELETE FROM watchlist WHERE user_id = $1 AND product_id = $2
+`
+
+type UnwatchProductParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ProductID uuid.UUID `json:"product_id"`
+}
+
+func (q *Queries) UnwatchProduct(ctx context.Context, arg UnwatchProductParams) error {
+	_, err := q.db.Exec(ctx, unwatchProduct, arg.UserID, arg.ProductID)
+	return err
+}
+
+const watchProduct = `-- name: WatchProduct :exec
+INSERT INTO watchlist (
+	user_id,
+	product_id
+) VALUES (
+	$1,
+	$2
+) ON CONFLICT DO NOTHING
+`
+
+type WatchProductParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ProductID uuid.UUID `json:"product_id"`
+}
+
+func (q *Queries) WatchProduct(ctx context.Context, arg WatchProductParams) error {
+	_, err := q.db.Exec(ctx, watchProduct, arg.UserID, arg.ProductID)
+	return err
+}
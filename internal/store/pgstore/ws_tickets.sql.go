@@ -0,0 +1,59 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: ws_tickets.sql
+
+package pgstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const consumeWsTicket = `-- name: ConsumeWsTicket :one
+DELETE FROM ws_tickets
+WHERE ticket_hash = $1 AND expires_at > now()
+RETURNING user_id
+`
+
+// Deleting the ticket as it is read makes it single use.
+func (q *Queries) ConsumeWsTicket(ctx context.Context, ticketHash []byte) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, consumeWsTicket, ticketHash)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const createWsTicket = `-- name: CreateWsTicket :exec
+INSERT INTO ws_tickets (
+	user_id,
+	ticket_hash,
+	expires_at
+) VALUES (
+	$1,
+	$2,
+	$3
+)
+`
+
+type CreateWsTicketParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	TicketHash []byte    `json:"ticket_hash"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateWsTicket(ctx context.Context, arg CreateWsTicketParams) error {
+	_, err := q.db.Exec(ctx, createWsTicket, arg.UserID, arg.TicketHash, arg.ExpiresAt)
+	return err
+}
+
+const deleteExpiredWsTickets = `-- name: DeleteExpiredWsTickets :exec
+DELETE FROM ws_tickets WHERE expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredWsTickets(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredWsTickets)
+	return err
+}
@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+type CloseAuctionReq struct {
+	Reason string `json:"reason"`
+}
+
+func (req CloseAuctionReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(validator.NotBlank(req.Reason), "reason", "this field cannot be empty")
+	eval.CheckField(
+		validator.MaxChars(req.Reason, 500),
+		"reason",
+		"this field must have at most 500 characters",
+	)
+
+	return eval
+}
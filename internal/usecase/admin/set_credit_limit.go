@@ -0,0 +1,38 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+// SetCreditLimitReq sets the limit in the currency, or removes it when
+// Amount is null.
+type SetCreditLimitReq struct {
+	Currency money.Currency `json:"currency"`
+	Amount   *money.Decimal `json:"amount"`
+}
+
+func (req SetCreditLimitReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(
+		req.Currency.Valid(),
+		"currency",
+		"must be a supported ISO 4217 currency code",
+	)
+
+	if req.Amount != nil {
+		amount, err := req.Amount.Money(req.Currency)
+		eval.CheckField(
+			err == nil,
+			"amount",
+			fmt.Sprintf("must be a decimal with at most %d decimal places", req.Currency.Exponent()),
+		)
+		eval.CheckField(amount >= 0, "amount", "must not be negative")
+	}
+
+	return eval
+}
@@ -0,0 +1,20 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/access"
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+type SetRoleReq struct {
+	Role access.Role `json:"role"`
+}
+
+func (req SetRoleReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(req.Role.Valid(), "role", "must be user, moderator or admin")
+
+	return eval
+}
@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+// SuspendUserReq suspends a user until lifted when DurationHours is left out.
+type SuspendUserReq struct {
+	Reason        string `json:"reason"`
+	DurationHours int    `json:"duration_hours"`
+}
+
+// maxSuspensionHours caps timed suspensions at ten years, well below the
+// point where the duration in hours overflows a time.Duration.
+const maxSuspensionHours = 10 * 365 * 24
+
+func (req SuspendUserReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(validator.NotBlank(req.Reason), "reason", "this field cannot be empty")
+	eval.CheckField(
+		validator.MaxChars(req.Reason, 500),
+		"reason",
+		"this field must have at most 500 characters",
+	)
+	eval.CheckField(req.DurationHours >= 0, "duration_hours", "must not be negative")
+	eval.CheckField(
+		req.DurationHours <= maxSuspensionHours,
+		"duration_hours",
+		fmt.Sprintf("must be at most %d, leave it out to suspend until lifted", maxSuspensionHours),
+	)
+
+	return eval
+}
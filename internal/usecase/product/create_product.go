@@ -2,19 +2,38 @@ package product
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/nathancamolez-dev/go-bid/internal/money"
 	"github.com/nathancamolez-dev/go-bid/internal/validator"
 )
 
 type CreateProductReq struct {
-	SellerID    uuid.UUID `json:"seller_id"`
-	ProductName string    `json:"product_name"`
-	Description string    `json:"description"`
-	Baseprice   float64   `json:"baseprice"`
-	AuctionEnd  time.Time `json:"auction_end"`
+	SellerID    uuid.UUID      `json:"seller_id"`
+	ProductName string         `json:"product_name"`
+	Description string         `json:"description"`
+	Baseprice   money.Decimal  `json:"baseprice"`
+	Currency    money.Currency `json:"currency"`
+	AuctionEnd  time.Time      `json:"auction_end"`
+}
+
+// UnmarshalJSON decodes the request, leaving products sent without a
+// currency in money.DefaultCurrency as they were before currencies existed.
+func (req *CreateProductReq) UnmarshalJSON(data []byte) error {
+	type plain CreateProductReq
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Currency == "" {
+		decoded.Currency = money.DefaultCurrency
+	}
+	*req = CreateProductReq(decoded)
+	return nil
 }
 
 const minAuctionDuration = 2 * time.Hour
@@ -40,7 +59,20 @@ func (req CreateProductReq) Valid(ctx context.Context) validator.Evaluator {
 	)
 
 	eval.CheckField(
-		validator.NonNegativeValue(req.Baseprice, 0),
+		req.Currency.Valid(),
+		"currency",
+		"must be a supported ISO 4217 currency code",
+	)
+
+	baseprice, err := req.Baseprice.Money(req.Currency)
+	eval.CheckField(
+		err == nil,
+		"baseprice",
+		fmt.Sprintf("must be a decimal with at most %d decimal places", req.Currency.Exponent()),
+	)
+
+	eval.CheckField(
+		validator.NonNegativeValue(baseprice, 0),
 		"baseprice",
 		"must be greater than 0",
 	)
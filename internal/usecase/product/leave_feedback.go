@@ -0,0 +1,29 @@
+package product
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+type LeaveFeedbackReq struct {
+	Rating  int16  `json:"rating"`
+	Comment string `json:"comment"`
+}
+
+func (req LeaveFeedbackReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(
+		req.Rating >= 1 && req.Rating <= 5,
+		"rating",
+		"must be between 1 and 5",
+	)
+	eval.CheckField(
+		validator.MaxChars(req.Comment, 500),
+		"comment",
+		"this field must have at most 500 characters",
+	)
+
+	return eval
+}
@@ -0,0 +1,25 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (req ChangePasswordReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(validator.NotBlank(req.CurrentPassword), "current_password", "must be provided")
+	eval.CheckField(
+		validator.MinChars(req.NewPassword, 8),
+		"new_password",
+		"this field must have at least 8 characters",
+	)
+
+	return eval
+}
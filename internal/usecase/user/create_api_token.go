@@ -0,0 +1,43 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/access"
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+// CreateApiTokenReq creates a token that never expires when ExpiresInDays is
+// left out.
+type CreateApiTokenReq struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+func (req CreateApiTokenReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(validator.NotBlank(req.Name), "name", "this field cannot be empty")
+	eval.CheckField(
+		validator.MaxChars(req.Name, 50),
+		"name",
+		"this field must have at most 50 characters",
+	)
+
+	eval.CheckField(len(req.Scopes) > 0, "scopes", "at least one scope must be granted")
+	for _, scope := range req.Scopes {
+		if !access.ValidScope(scope) {
+			eval.AddFieldError("scopes", "unknown scope "+scope)
+			break
+		}
+	}
+
+	eval.CheckField(
+		req.ExpiresInDays >= 0 && req.ExpiresInDays <= 365,
+		"expires_in_days",
+		"must be between 1 and 365, or left out",
+	)
+
+	return eval
+}
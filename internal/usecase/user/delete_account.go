@@ -0,0 +1,18 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+// DeleteAccountReq confirms the deletion with the password. Users of an
+// identity provider can leave it out after logging in again through it.
+type DeleteAccountReq struct {
+	Password string `json:"password"`
+}
+
+func (req DeleteAccountReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+	return eval
+}
@@ -0,0 +1,41 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+func (req ForgotPasswordReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(
+		validator.Matches(req.Email, validator.EmailRX),
+		"email",
+		"must be a valid email",
+	)
+
+	return eval
+}
+
+type ResetPasswordReq struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (req ResetPasswordReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(validator.NotBlank(req.Token), "token", "must be provided")
+	eval.CheckField(
+		validator.MinChars(req.Password, 8),
+		"password",
+		"this field must have at least 8 characters",
+	)
+
+	return eval
+}
@@ -0,0 +1,24 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/money"
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+type SetDisplayCurrencyReq struct {
+	Currency money.Currency `json:"currency"`
+}
+
+func (req SetDisplayCurrencyReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(
+		req.Currency == "" || req.Currency.Valid(),
+		"currency",
+		"must be a supported ISO 4217 currency code",
+	)
+
+	return eval
+}
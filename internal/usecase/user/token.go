@@ -0,0 +1,55 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+const (
+	PasswordGrant     = "password"
+	RefreshTokenGrant = "refresh_token"
+)
+
+// TokenReq asks for access and refresh tokens, either with the user's
+// credentials or with a refresh token. Code is the two factor code of users
+// who enabled it.
+type TokenReq struct {
+	GrantType    string `json:"grant_type"`
+	Email        string `json:"email"`
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (req TokenReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	switch req.GrantType {
+	case PasswordGrant:
+		eval.CheckField(
+			validator.Matches(req.Email, validator.EmailRX),
+			"email",
+			"must be a valid email",
+		)
+		eval.CheckField(validator.NotBlank(req.Password), "password", "must be provided")
+	case RefreshTokenGrant:
+		eval.CheckField(validator.NotBlank(req.RefreshToken), "refresh_token", "must be provided")
+	default:
+		eval.AddFieldError("grant_type", "must be password or refresh_token")
+	}
+
+	return eval
+}
+
+type RevokeTokenReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (req RevokeTokenReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(validator.NotBlank(req.RefreshToken), "refresh_token", "must be provided")
+
+	return eval
+}
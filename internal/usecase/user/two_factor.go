@@ -0,0 +1,32 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+// TwoFactorCodeReq carries a TOTP code, or a recovery code when logging in.
+type TwoFactorCodeReq struct {
+	Code string `json:"code"`
+}
+
+func (req TwoFactorCodeReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(validator.NotBlank(req.Code), "code", "must be provided")
+
+	return eval
+}
+
+type DisableTwoFactorReq struct {
+	Password string `json:"password"`
+}
+
+func (req DisableTwoFactorReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(validator.NotBlank(req.Password), "password", "must be provided")
+
+	return eval
+}
@@ -0,0 +1,37 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+// UpdateProfileReq only changes the fields that are present.
+type UpdateProfileReq struct {
+	UserName *string `json:"user_name"`
+	Bio      *string `json:"bio"`
+}
+
+func (req UpdateProfileReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	if req.UserName != nil {
+		eval.CheckField(validator.NotBlank(*req.UserName), "user_name", "this field cannot be empty")
+		eval.CheckField(
+			validator.MaxChars(*req.UserName, 50),
+			"user_name",
+			"this field must have at most 50 characters",
+		)
+	}
+
+	if req.Bio != nil {
+		eval.CheckField(validator.NotBlank(*req.Bio), "bio", "this field cannot be empty")
+		eval.CheckField(
+			validator.MinChars(*req.Bio, 10) && validator.MaxChars(*req.Bio, 100),
+			"bio",
+			"this field must have at least 10 characters and at most 100 characters",
+		)
+	}
+
+	return eval
+}
@@ -0,0 +1,35 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nathancamolez-dev/go-bid/internal/validator"
+)
+
+type VerifyEmailReq struct {
+	Token string `json:"token"`
+}
+
+func (req VerifyEmailReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(validator.NotBlank(req.Token), "token", "must be provided")
+
+	return eval
+}
+
+type ResendVerificationReq struct {
+	Email string `json:"email"`
+}
+
+func (req ResendVerificationReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+
+	eval.CheckField(
+		validator.Matches(req.Email, validator.EmailRX),
+		"email",
+		"must be a valid email",
+	)
+
+	return eval
+}
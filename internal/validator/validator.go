@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"cmp"
 	"context"
 	"regexp"
 	"strings"
@@ -49,6 +50,6 @@ func Matches(value string, rx *regexp.Regexp) bool {
 	return rx.MatchString(value)
 }
 
-func NonNegativeValue(value float64, n float64) bool {
+func NonNegativeValue[T cmp.Ordered](value T, n T) bool {
 	return value > n
 }